@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Geralt28/gator/internal/database"
+	"github.com/Geralt28/gator/internal/extract"
+	"github.com/google/uuid"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// articleTimeout limits time of downloading one article
+	articleTimeout = 15 * time.Second
+	// maxArticleSize is the biggest web page read while extracting article
+	maxArticleSize = 5 << 20
+)
+
+// fetchArticle downloads web page of the post and returns its main content as clean HTML
+func fetchArticle(ctx context.Context, client *httpClient, articleURL string) (string, error) {
+	req, err := client.newRequest(ctx, articleURL)
+	if err != nil {
+		return "", err
+	}
+	res, err := client.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("not a web page: %s", mediaType)
+	}
+	// pages in other encodings than UTF-8 are converted, charset is taken from Content-Type or <meta>
+	body, err := charset.NewReader(io.LimitReader(res.Body, maxArticleSize), res.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+	article, err := extract.FromHTML(body)
+	if err != nil {
+		return "", err
+	}
+	return article.HTML, nil
+}
+
+// newPost is a post just saved by scrapeFeed
+type newPost struct {
+	id  uuid.UUID
+	url string
+}
+
+// extractArticles replaces content of new posts with articles downloaded from their links.
+// When article can not be extracted post keeps content from the feed.
+func extractArticles(ctx context.Context, s *state, posts []newPost, limiter *hostLimiter, out io.Writer) {
+	for _, post := range posts {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// agg is shutting down
+			return
+		}
+		// article has own timeout, the one of the feed could already be used up by fetching
+		articleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), articleTimeout)
+		err := limiter.wait(articleCtx, post.url)
+		if err != nil {
+			cancel()
+			fmt.Fprintln(out, "error: could not extract article:", post.url, err)
+			continue
+		}
+		content, err := fetchArticle(articleCtx, s.client, post.url)
+		if err != nil {
+			cancel()
+			fmt.Fprintln(out, "error: could not extract article:", post.url, err)
+			continue
+		}
+		err = s.db.UpdatePostContent(articleCtx, database.UpdatePostContentParams{
+			ID:      post.id,
+			Content: content,
+		})
+		cancel()
+		if err != nil {
+			fmt.Fprintln(out, "error: could not save article:", post.url, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+)
+
+// ******** START:  Struct for Atom 1.0 feed *********
+
+type Atom struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle"`
+	Lang      string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Generator string      `xml:"generator"`
+	Icon      string      `xml:"icon"`
+	Logo      string      `xml:"logo"`
+	Links     []AtomLink  `xml:"link"`
+	Entries   []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+// AtomText is a text construct: type="text" and "html" keep the text (html escaped) as character data,
+// type="xhtml" keeps markup inside a <div> element, which encoding/xml would skip in a string field
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// xhtmlDivPattern finds content of the <div> wrapping xhtml text construct
+var xhtmlDivPattern = regexp.MustCompile(`(?s)^<(?:\w+:)?div[^>]*>(.*)</(?:\w+:)?div>$`)
+
+// String returns text of the construct, for xhtml the markup inside its <div>
+func (t AtomText) String() string {
+	if t.Type != "xhtml" {
+		return t.Text
+	}
+	inner := strings.TrimSpace(t.InnerXML)
+	if match := xhtmlDivPattern.FindStringSubmatch(inner); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return inner
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// ******** END:  Struct for Atom 1.0 feed *********
+
+// atomLink returns the rel="alternate" link (a link without rel is alternate too),
+// falling back to the first link if there is no alternate one
+func atomLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+// toRSS takes summary of the entry as description (content if there is none) and published date,
+// or updated date when the entry was never published
+func (a *Atom) toRSS() *RSS {
+	rss := &RSS{
+		Channel: Channel{
+			Title:       a.Title,
+			Link:        atomLink(a.Links),
+			Description: a.Subtitle,
+			Language:    a.Lang,
+			Generator:   a.Generator,
+		},
+	}
+	if image := a.Logo; image != "" || a.Icon != "" {
+		if image == "" {
+			image = a.Icon
+		}
+		rss.Channel.Images = []RSSImage{{URL: strings.TrimSpace(image)}}
+	}
+	for _, entry := range a.Entries {
+		description := entry.Summary.String()
+		if strings.TrimSpace(description) == "" {
+			description = entry.Content.String()
+		}
+		pubDate := entry.Published
+		if strings.TrimSpace(pubDate) == "" {
+			pubDate = entry.Updated
+		}
+		var authors []string
+		for _, author := range entry.Authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+		var categories []string
+		for _, category := range entry.Categories {
+			if category.Label != "" {
+				categories = append(categories, category.Label)
+			} else {
+				categories = append(categories, category.Term)
+			}
+		}
+		rss.Channel.Items = append(rss.Channel.Items, Item{
+			Title:       entry.Title,
+			Link:        atomLink(entry.Links),
+			Description: description,
+			Content:     entry.Content.String(),
+			GUID:        entry.ID,
+			Author:      strings.Join(authors, ", "),
+			Categories:  categories,
+			PubDate:     strings.TrimSpace(pubDate),
+		})
+	}
+	return rss
+}
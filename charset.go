@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// xmlEncodingPattern finds encoding given in the XML declaration: <?xml version="1.0" encoding="ISO-8859-2"?>
+var xmlEncodingPattern = regexp.MustCompile(`^(\s*<\?xml[^>]*?\sencoding\s*=\s*)["'][^"']*["']`)
+
+// newXMLDecoder returns decoder which converts documents into UTF-8 using encoding from their XML declaration
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder
+}
+
+// toUTF8 converts feed into UTF-8 using charset from Content-Type. Charset of the header is more important
+// than the XML declaration, so after conversion the declaration is changed to UTF-8. If there is no charset
+// in the header, or it says UTF-8 but body is not valid UTF-8, data is left for the XML declaration.
+func toUTF8(data []byte, contentType string) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	_, params, _ := mime.ParseMediaType(contentType)
+	label := strings.ToLower(strings.TrimSpace(params["charset"]))
+	switch label {
+	case "":
+		return data
+	case "utf-8", "utf8":
+		if !utf8.Valid(data) {
+			return data
+		}
+	default:
+		encoding, _ := charset.Lookup(label)
+		if encoding == nil {
+			return data
+		}
+		converted, err := encoding.NewDecoder().Bytes(data)
+		if err != nil {
+			return data
+		}
+		data = converted
+	}
+	return xmlEncodingPattern.ReplaceAll(data, []byte(`${1}"UTF-8"`))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// feedLinkTypes are types of <link rel="alternate"> pointing to a feed
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// commonFeedPaths are tried when web page does not link its feed
+var commonFeedPaths = []string{"/feed", "/rss.xml", "/index.xml", "/atom.xml", "/feed.xml", "/rss", "/feed.json"}
+
+// feedCandidate is a feed found on a web page
+type feedCandidate struct {
+	URL   string
+	Title string
+}
+
+// discoverFeeds returns feeds available under pageURL: the url itself if it is a feed, feeds linked
+// by <link rel="alternate"> tags of the page or, if there are none, feeds found under common paths
+func discoverFeeds(ctx context.Context, client *httpClient, pageURL string) ([]feedCandidate, error) {
+	data, contentType, err := fetchPage(ctx, client, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if rss, err := parseFeed(data, contentType); err == nil {
+		return []feedCandidate{{URL: pageURL, Title: rss.Channel.Title}}, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%s is neither a feed nor a web page (%s)", pageURL, contentType)
+	}
+	base, err := neturl.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := feedLinks(data, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+	for _, path := range commonFeedPaths {
+		candidateURL := base.ResolveReference(&neturl.URL{Path: path}).String()
+		data, contentType, err := fetchPage(ctx, client, candidateURL)
+		if err != nil {
+			continue
+		}
+		if rss, err := parseFeed(data, contentType); err == nil {
+			candidates = append(candidates, feedCandidate{URL: candidateURL, Title: rss.Channel.Title})
+		}
+	}
+	return candidates, nil
+}
+
+// fetchPage downloads any url and returns its body and Content-Type
+func fetchPage(ctx context.Context, client *httpClient, pageURL string) ([]byte, string, error) {
+	req, err := client.newRequest(ctx, pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := client.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s answered with status: %s", pageURL, res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, res.Header.Get("Content-Type"), nil
+}
+
+// feedLinks reads <link rel="alternate" type="application/rss+xml" href="..."> tags of the page
+func feedLinks(data []byte, base *neturl.URL) ([]feedCandidate, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []feedCandidate
+	seen := map[string]bool{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Link {
+			var rel, linkType, href, title string
+			for _, a := range n.Attr {
+				switch strings.ToLower(a.Key) {
+				case "rel":
+					rel = strings.ToLower(a.Val)
+				case "type":
+					linkType = strings.ToLower(strings.TrimSpace(a.Val))
+				case "href":
+					href = strings.TrimSpace(a.Val)
+				case "title":
+					title = a.Val
+				}
+			}
+			if strings.Contains(rel, "alternate") && feedLinkTypes[linkType] && href != "" {
+				if ref, err := neturl.Parse(href); err == nil {
+					feedURL := base.ResolveReference(ref).String()
+					if !seen[feedURL] {
+						seen[feedURL] = true
+						candidates = append(candidates, feedCandidate{URL: feedURL, Title: title})
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return candidates, nil
+}
+
+// chooseFeed asks user which of the found feeds should be added, one feed is chosen without asking
+func chooseFeed(candidates []feedCandidate) (feedCandidate, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	fmt.Println("Found", len(candidates), "feeds:")
+	for i, candidate := range candidates {
+		fmt.Printf("%d. %s (%s)\n", i+1, candidate.Title, candidate.URL)
+	}
+	fmt.Printf("Choose feed [1-%d]: ", len(candidates))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return feedCandidate{}, fmt.Errorf("no feed chosen")
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return feedCandidate{}, fmt.Errorf("invalid choice: %s", strings.TrimSpace(line))
+	}
+	return candidates[choice-1], nil
+}
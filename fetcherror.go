@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Errors returned by fetchFeed for responses which do not contain a feed, scrapeFeed handles each kind differently
+
+// notFoundError is returned when server answers 404 Not Found
+type notFoundError struct {
+	url string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s not found (404)", e.url)
+}
+
+// rateLimitedError is returned for 429 Too Many Requests and for 503 with Retry-After,
+// retryAfter is 0 when server did not say how long to wait
+type rateLimitedError struct {
+	url        string
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	if e.retryAfter > 0 {
+		return fmt.Sprintf("%s is rate limited (%s), retry after %s", e.url, e.status, e.retryAfter)
+	}
+	return fmt.Sprintf("%s is rate limited (%s)", e.url, e.status)
+}
+
+// serverError is returned for 5xx statuses, they are expected to be temporary
+type serverError struct {
+	url    string
+	status string
+}
+
+func (e *serverError) Error() string {
+	return fmt.Sprintf("%s answered with server error: %s", e.url, e.status)
+}
+
+// notAFeedError is returned when response is a web page, an image or anything else which can not be parsed as a feed
+type notAFeedError struct {
+	url         string
+	contentType string
+	err         error
+}
+
+func (e *notAFeedError) Error() string {
+	contentType := e.contentType
+	if contentType == "" {
+		contentType = "no Content-Type"
+	}
+	return fmt.Sprintf("%s is not a feed (%s): %v", e.url, contentType, e.err)
+}
+
+func (e *notAFeedError) Unwrap() error {
+	return e.err
+}
+
+// checkFeedStatus classifies response status, nil means the body should be a feed
+func checkFeedStatus(res *http.Response, feedURL string) error {
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusNotFound:
+		return &notFoundError{url: feedURL}
+	case res.StatusCode == http.StatusGone:
+		return errFeedGone
+	case res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode == http.StatusServiceUnavailable && res.Header.Get("Retry-After") != "":
+		return &rateLimitedError{
+			url:        feedURL,
+			status:     res.Status,
+			retryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	case res.Header.Get("Cf-Mitigated") == "challenge":
+		// Cloudflare shows a page with JavaScript challenge instead of the feed
+		return &notAFeedError{url: feedURL, contentType: res.Header.Get("Content-Type"), err: errors.New("blocked by Cloudflare challenge")}
+	case res.StatusCode >= 500:
+		return &serverError{url: feedURL, status: res.Status}
+	default:
+		return fmt.Errorf("%s answered with status: %s", feedURL, res.Status)
+	}
+}
+
+// checkFeedContent rejects bodies which surely are not feeds before they are given to the XML parser
+func checkFeedContent(contentType, feedURL string) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, prefix := range []string{"image/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return &notAFeedError{url: feedURL, contentType: contentType, err: errors.New("unexpected content type")}
+		}
+	}
+	return nil
+}
+
+// isWebPage tells if a body which failed to parse as a feed is HTML. Content-Type of feeds is often wrong,
+// so the body itself is checked, and only after parsing because feeds starting with a comment sniff as HTML too
+func isWebPage(data []byte) bool {
+	if root, err := feedRootElement(data); err == nil && strings.EqualFold(root.Local, "html") {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType(data), "text/html")
+}
@@ -3,6 +3,10 @@ module github.com/Geralt28/gator
 go 1.23.5
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/andybalholm/brotli v1.1.1
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.38.0
 )
+
+require golang.org/x/text v0.23.0 // indirect
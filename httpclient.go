@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Geralt28/gator/internal/config"
+	"github.com/andybalholm/brotli"
+)
+
+// Settings of the http client used when .gatorconfig.json does not give them
+const (
+	defaultHTTPTimeout  = 30 * time.Second
+	defaultUserAgent    = "Gator"
+	defaultMaxBodyBytes = 10 << 20
+	// acceptEncoding lists compressions decoded by decompressBody
+	acceptEncoding = "gzip, deflate, br"
+)
+
+// tlsVersions are accepted values of tls_min_version
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// errBodyTooLarge is returned while reading response bigger than max_body_bytes
+var errBodyTooLarge = errors.New("response body too large")
+
+// httpClient sends all outbound requests of gator: feeds, web pages searched for feeds and articles
+type httpClient struct {
+	client       *http.Client
+	userAgent    string
+	maxBodyBytes int64
+}
+
+// newHTTPClient builds client from http settings of the config, settings not given get defaults
+func newHTTPClient(cfg *config.Config) (*httpClient, error) {
+	timeout := defaultHTTPTimeout
+	if cfg.Http_timeout != "" {
+		parsed, err := time.ParseDuration(cfg.Http_timeout)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid http_timeout: %s", cfg.Http_timeout)
+		}
+		timeout = parsed
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Http_proxy != "" {
+		proxyURL, err := neturl.Parse(cfg.Http_proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid http_proxy: %s", cfg.Http_proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := &httpClient{
+		client:       &http.Client{Transport: transport, Timeout: timeout},
+		userAgent:    defaultUserAgent,
+		maxBodyBytes: defaultMaxBodyBytes,
+	}
+	if cfg.User_agent != "" {
+		client.userAgent = cfg.User_agent
+	}
+	if cfg.Max_body_bytes < 0 {
+		return nil, fmt.Errorf("invalid max_body_bytes: %d", cfg.Max_body_bytes)
+	}
+	if cfg.Max_body_bytes > 0 {
+		client.maxBodyBytes = cfg.Max_body_bytes
+	}
+	return client, nil
+}
+
+// fetchTimeout limits fetching one feed: feedTimeout, or http_timeout when it is longer,
+// because the whole fetch can not take less time than the single request it is made of
+func (c *httpClient) fetchTimeout() time.Duration {
+	return max(feedTimeout, c.client.Timeout)
+}
+
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.Tls_insecure_skip_verify,
+	}
+	if cfg.Tls_min_version != "" {
+		version, ok := tlsVersions[cfg.Tls_min_version]
+		if !ok {
+			return nil, fmt.Errorf("invalid tls_min_version: %s (expected 1.0, 1.1, 1.2 or 1.3)", cfg.Tls_min_version)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if cfg.Tls_ca_file != "" {
+		// certificates of the file are trusted in addition to the system ones
+		pem, err := os.ReadFile(cfg.Tls_ca_file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tls_ca_file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls_ca_file: %s", cfg.Tls_ca_file)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// newRequest creates GET request with User-Agent of gator which accepts compressed responses
+func (c *httpClient) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	// with Accept-Encoding set here the transport does not decompress gzip itself, decompressBody does it
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	return req, nil
+}
+
+// do sends request, reading body of the response fails with errBodyTooLarge after maxBodyBytes
+func (c *httpClient) do(req *http.Request) (*http.Response, error) {
+	return c.send(c.client, req)
+}
+
+// doTrackingRedirects sends request like do and returns also where the resource moved:
+// the last url reached only by permanent redirects (301, 308), empty if there were none
+func (c *httpClient) doTrackingRedirects(req *http.Request) (*http.Response, string, error) {
+	movedTo := ""
+	permanent := true
+	client := *c.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		status := req.Response.StatusCode
+		permanent = permanent && (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect)
+		if permanent {
+			movedTo = req.URL.String()
+		}
+		return nil
+	}
+	res, err := c.send(&client, req)
+	return res, movedTo, err
+}
+
+func (c *httpClient) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.ContentLength > c.maxBodyBytes {
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes (limit %d)", errBodyTooLarge, res.ContentLength, c.maxBodyBytes)
+	}
+	if err := decompressBody(res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	// limit is checked after decompression, so small compressed response can not blow up in memory
+	res.Body = &limitedBody{ReadCloser: res.Body, remaining: c.maxBodyBytes, limit: c.maxBodyBytes}
+	return res, nil
+}
+
+// decompressBody replaces body of the response with its decompressed content,
+// Content-Encoding can list several encodings in the order they were applied
+func decompressBody(res *http.Response) error {
+	contentEncoding := res.Header.Get("Content-Encoding")
+	if contentEncoding == "" || res.StatusCode == http.StatusNotModified || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	encodings := strings.Split(contentEncoding, ",")
+	var reader io.Reader = res.Body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch strings.ToLower(strings.TrimSpace(encodings[i])) {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(reader)
+			if err != nil {
+				return fmt.Errorf("could not decompress gzip response: %v", err)
+			}
+			reader = gz
+		case "deflate":
+			deflate, err := newDeflateReader(reader)
+			if err != nil {
+				return fmt.Errorf("could not decompress deflate response: %v", err)
+			}
+			reader = deflate
+		case "br":
+			reader = brotli.NewReader(reader)
+		default:
+			return fmt.Errorf("unsupported Content-Encoding: %s", contentEncoding)
+		}
+	}
+	res.Body = &decompressedBody{Reader: reader, Closer: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
+
+// newDeflateReader reads "deflate" encoding, which should be zlib stream, but some servers send raw deflate
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decompressedBody reads decompressed data and closes the original body
+type decompressedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedBody returns error instead of silently cutting the body like io.LimitReader does
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// limit is reached, body is fine only if nothing is left
+		var one [1]byte
+		n, err := b.ReadCloser.Read(one[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: over %d bytes", errBodyTooLarge, b.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
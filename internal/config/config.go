@@ -10,6 +10,15 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	Db_url            string `json:"db_url"`
 	Current_user_name string `json:"current_user_name"`
+	Fetch_interval    string `json:"fetch_interval,omitempty"`
+	// settings of the http client, defaults are used when they are not given
+	Http_timeout             string `json:"http_timeout,omitempty"`
+	Http_proxy               string `json:"http_proxy,omitempty"`
+	User_agent               string `json:"user_agent,omitempty"`
+	Max_body_bytes           int64  `json:"max_body_bytes,omitempty"`
+	Tls_insecure_skip_verify bool   `json:"tls_insecure_skip_verify,omitempty"`
+	Tls_ca_file              string `json:"tls_ca_file,omitempty"`
+	Tls_min_version          string `json:"tls_min_version,omitempty"`
 }
 
 func (c *Config) SetUser(user string) error {
@@ -12,13 +12,29 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           sql.NullString
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 sql.NullString
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	FetchInterval       int32
+	NextFetchAt         sql.NullTime
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	ExtractContent      bool
+	Description         sql.NullString
+	SiteLink            sql.NullString
+	Title               sql.NullString
+	ImageUrl            sql.NullString
+	Language            sql.NullString
+	Generator           sql.NullString
+	Active              bool
+	DeactivatedReason   sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,6 +43,7 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 }
 
 type Post struct {
@@ -39,6 +56,21 @@ type Post struct {
 	Content     string
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Author      sql.NullString
+	Categories  []string
+	ContentHash string
+	Revisions   int32
+}
+
+type PostRevision struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	PostID      uuid.UUID
+	Title       string
+	Description string
+	Content     string
+	ContentHash string
 }
 
 type User struct {
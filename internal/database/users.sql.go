@@ -11,28 +11,52 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const adoptLegacyPost = `-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.feed_id = $2 AND posts.url = $3 AND posts.guid = posts.url AND posts.guid <> $1
+    AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = $2 AND p.guid = $1)
+`
+
+type AdoptLegacyPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_link)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, last_error, last_error_at, consecutive_failures, extract_content, description, site_link, title, image_url, language, generator, active, deactivated_reason
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       sql.NullString
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         sql.NullString
+	UserID      uuid.UUID
+	Description sql.NullString
+	SiteLink    sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -43,6 +67,8 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Description,
+		arg.SiteLink,
 	)
 	var i Feed
 	err := row.Scan(
@@ -53,6 +79,22 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.ExtractContent,
+		&i.Description,
+		&i.SiteLink,
+		&i.Title,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Active,
+		&i.DeactivatedReason,
 	)
 	return i, err
 }
@@ -67,10 +109,10 @@ VALUES (
     (SELECT id FROM users WHERE users.name = $1), 
     (SELECT id FROM feeds WHERE feeds.url = $2)
     )
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, folder
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -89,6 +131,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -102,14 +145,21 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
-const createPost = `-- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id)
+const createPost = `-- name: CreatePost :one
+WITH post_revision AS (
+    INSERT INTO post_revisions (id, created_at, post_id, title, description, content, content_hash)
+    SELECT gen_random_uuid(), CURRENT_TIMESTAMP, posts.id, posts.title, posts.description, posts.content, posts.content_hash
+    FROM posts
+    WHERE posts.feed_id = $6 AND posts.guid = $7 AND posts.content_hash <> $10 AND posts.content_hash <> ''
+)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id, guid, author, categories, content_hash)
 VALUES (
     gen_random_uuid(),
     CURRENT_TIMESTAMP,
@@ -119,8 +169,25 @@ VALUES (
     $3,
     $4,
     $5,
-    $6 
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    published_at = EXCLUDED.published_at,
+    author = EXCLUDED.author,
+    categories = EXCLUDED.categories,
+    content_hash = EXCLUDED.content_hash,
+    revisions = posts.revisions + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, (xmax = 0) AS inserted
 `
 
 type CreatePostParams struct {
@@ -130,18 +197,33 @@ type CreatePostParams struct {
 	Content     string
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Author      sql.NullString
+	Categories  []string
+	ContentHash string
+}
+
+type CreatePostRow struct {
+	ID       uuid.UUID
+	Inserted bool
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreatePostRow, error) {
+	row := q.db.QueryRowContext(ctx, createPost,
 		arg.Title,
 		arg.Url,
 		arg.Description,
 		arg.Content,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.Author,
+		pq.Array(arg.Categories),
+		arg.ContentHash,
 	)
-	return err
+	var i CreatePostRow
+	err := row.Scan(&i.ID, &i.Inserted)
+	return i, err
 }
 
 const createUser = `-- name: CreateUser :one
@@ -179,6 +261,34 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	return i, err
 }
 
+const deactivateFeed = `-- name: DeactivateFeed :exec
+UPDATE feeds
+SET active = FALSE,
+    deactivated_reason = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type DeactivateFeedParams struct {
+	ID                uuid.UUID
+	DeactivatedReason sql.NullString
+}
+
+func (q *Queries) DeactivateFeed(ctx context.Context, arg DeactivateFeedParams) error {
+	_, err := q.db.ExecContext(ctx, deactivateFeed, arg.ID, arg.DeactivatedReason)
+	return err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
 USING feeds
@@ -204,16 +314,129 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 	return err
 }
 
+const getFeedByUrl = `-- name: GetFeedByUrl :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, last_error, last_error_at, consecutive_failures, extract_content, description, site_link, title, image_url, language, generator, active, deactivated_reason FROM feeds
+WHERE url = $1
+`
+
+func (q *Queries) GetFeedByUrl(ctx context.Context, url sql.NullString) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByUrl, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.ExtractContent,
+		&i.Description,
+		&i.SiteLink,
+		&i.Title,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Active,
+		&i.DeactivatedReason,
+	)
+	return i, err
+}
+
+const getFeedDetails = `-- name: GetFeedDetails :one
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.etag, feeds.last_modified, feeds.fetch_interval, feeds.next_fetch_at, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.extract_content, feeds.description, feeds.site_link, feeds.title, feeds.image_url, feeds.language, feeds.generator, feeds.active, feeds.deactivated_reason, users.name AS user_name,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count
+FROM feeds
+LEFT JOIN users ON feeds.user_id = users.id
+WHERE feeds.name = $1 OR feeds.url = $1
+LIMIT 1
+`
+
+type GetFeedDetailsRow struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 sql.NullString
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	FetchInterval       int32
+	NextFetchAt         sql.NullTime
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	ExtractContent      bool
+	Description         sql.NullString
+	SiteLink            sql.NullString
+	Title               sql.NullString
+	ImageUrl            sql.NullString
+	Language            sql.NullString
+	Generator           sql.NullString
+	Active              bool
+	DeactivatedReason   sql.NullString
+	UserName            sql.NullString
+	PostCount           int64
+	FollowerCount       int64
+}
+
+func (q *Queries) GetFeedDetails(ctx context.Context, name string) (GetFeedDetailsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDetails, name)
+	var i GetFeedDetailsRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.ExtractContent,
+		&i.Description,
+		&i.SiteLink,
+		&i.Title,
+		&i.ImageUrl,
+		&i.Language,
+		&i.Generator,
+		&i.Active,
+		&i.DeactivatedReason,
+		&i.UserName,
+		&i.PostCount,
+		&i.FollowerCount,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feeds.name AS feedName, users.name As userName FROM feed_follows
+SELECT feeds.name AS feedName, users.name As userName, feeds.url, feeds.site_link, feed_follows.folder FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name
 `
 
 type GetFeedFollowsForUserRow struct {
 	Feedname string
 	Username string
+	Url      sql.NullString
+	SiteLink sql.NullString
+	Folder   sql.NullString
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -225,7 +448,13 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.Feedname, &i.Username); err != nil {
+		if err := rows.Scan(
+			&i.Feedname,
+			&i.Username,
+			&i.Url,
+			&i.SiteLink,
+			&i.Folder,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -272,14 +501,118 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
+const getFeedsByNameOrUrl = `-- name: GetFeedsByNameOrUrl :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, last_error, last_error_at, consecutive_failures, extract_content, description, site_link, title, image_url, language, generator, active, deactivated_reason FROM feeds
+WHERE name = ANY($1::text[]) OR url = ANY($1::text[])
+ORDER BY name
+`
+
+func (q *Queries) GetFeedsByNameOrUrl(ctx context.Context, names []string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByNameOrUrl, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.ExtractContent,
+			&i.Description,
+			&i.SiteLink,
+			&i.Title,
+			&i.ImageUrl,
+			&i.Language,
+			&i.Generator,
+			&i.Active,
+			&i.DeactivatedReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsStatus = `-- name: GetFeedsStatus :many
+SELECT name, url, last_fetched_at, next_fetch_at, last_error, last_error_at, consecutive_failures, active, deactivated_reason FROM feeds
+ORDER BY consecutive_failures DESC, name
+`
+
+type GetFeedsStatusRow struct {
+	Name                string
+	Url                 sql.NullString
+	LastFetchedAt       sql.NullTime
+	NextFetchAt         sql.NullTime
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	Active              bool
+	DeactivatedReason   sql.NullString
+}
+
+func (q *Queries) GetFeedsStatus(ctx context.Context) ([]GetFeedsStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsStatusRow
+	for rows.Next() {
+		var i GetFeedsStatusRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.Active,
+			&i.DeactivatedReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at DESC NULLS FIRST
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, last_error, last_error_at, consecutive_failures, extract_content, description, site_link, title, image_url, language, generator, active, deactivated_reason FROM feeds
+WHERE active AND (next_fetch_at IS NULL OR next_fetch_at <= CURRENT_TIMESTAMP + $1::integer * INTERVAL '1 second')
+ORDER BY last_fetched_at ASC NULLS FIRST
 `
 
 // LIMIT 1
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedToFetch)
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, dueWithin int32) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedToFetch, dueWithin)
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +628,22 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.ExtractContent,
+			&i.Description,
+			&i.SiteLink,
+			&i.Title,
+			&i.ImageUrl,
+			&i.Language,
+			&i.Generator,
+			&i.Active,
+			&i.DeactivatedReason,
 		); err != nil {
 			return nil, err
 		}
@@ -310,7 +659,7 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) ([]Feed, error) {
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, title, url, description, content, published_at, posts.feed_id, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, user_id, feed_follows.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, title, url, description, content, published_at, posts.feed_id, guid, author, categories, content_hash, revisions, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, user_id, feed_follows.feed_id, folder FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 WHERE feed_follows.user_id = $1
 ORDER BY posts.updated_at 
@@ -332,11 +681,17 @@ type GetPostsForUserRow struct {
 	Content     string
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Author      sql.NullString
+	Categories  []string
+	ContentHash string
+	Revisions   int32
 	ID_2        uuid.UUID
 	CreatedAt_2 time.Time
 	UpdatedAt_2 time.Time
 	UserID      uuid.UUID
 	FeedID_2    uuid.UUID
+	Folder      sql.NullString
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
@@ -358,11 +713,17 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Content,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.ContentHash,
+			&i.Revisions,
 			&i.ID_2,
 			&i.CreatedAt_2,
 			&i.UpdatedAt_2,
 			&i.UserID,
 			&i.FeedID_2,
+			&i.Folder,
 		); err != nil {
 			return nil, err
 		}
@@ -421,9 +782,34 @@ func (q *Queries) GetUsers(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
+const importFeedFollow = `-- name: ImportFeedFollow :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+VALUES (gen_random_uuid(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3)
+ON CONFLICT ON CONSTRAINT user_feed_constr DO UPDATE
+SET folder = COALESCE(EXCLUDED.folder, feed_follows.folder),
+    updated_at = CURRENT_TIMESTAMP
+RETURNING (xmax = 0) AS inserted
+`
+
+type ImportFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Folder sql.NullString
+}
+
+func (q *Queries) ImportFeedFollow(ctx context.Context, arg ImportFeedFollowParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, importFeedFollow, arg.UserID, arg.FeedID, arg.Folder)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
 SET last_fetched_at = CURRENT_TIMESTAMP,
+    consecutive_failures = 0,
+    active = TRUE,
+    deactivated_reason = NULL,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 `
@@ -432,3 +818,240 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+SELECT gen_random_uuid(), created_at, CURRENT_TIMESTAMP, user_id, $1, folder
+FROM feed_follows
+WHERE feed_id = $2
+ON CONFLICT ON CONSTRAINT user_feed_constr DO NOTHING
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const moveFeedPosts = `-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = $2
+    AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = $1)
+`
+
+type MoveFeedPostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedPosts(ctx context.Context, arg MoveFeedPostsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedPosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const postponeFeedFetch = `-- name: PostponeFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = CURRENT_TIMESTAMP + $1::integer * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $2
+`
+
+type PostponeFeedFetchParams struct {
+	Delay int32
+	ID    uuid.UUID
+}
+
+func (q *Queries) PostponeFeedFetch(ctx context.Context, arg PostponeFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, postponeFeedFetch, arg.Delay, arg.ID)
+	return err
+}
+
+const prunePostRevisions = `-- name: PrunePostRevisions :exec
+DELETE FROM post_revisions
+WHERE post_id = $1 AND id NOT IN (
+    SELECT id FROM post_revisions
+    WHERE post_id = $1
+    ORDER BY created_at DESC
+    LIMIT $2
+)
+`
+
+type PrunePostRevisionsParams struct {
+	PostID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) PrunePostRevisions(ctx context.Context, arg PrunePostRevisionsParams) error {
+	_, err := q.db.ExecContext(ctx, prunePostRevisions, arg.PostID, arg.Limit)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET last_error = $1,
+    last_error_at = CURRENT_TIMESTAMP,
+    consecutive_failures = consecutive_failures + 1,
+    next_fetch_at = CURRENT_TIMESTAMP + $2::integer * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $3
+`
+
+type RecordFeedFailureParams struct {
+	LastError sql.NullString
+	Backoff   int32
+	ID        uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure, arg.LastError, arg.Backoff, arg.ID)
+	return err
+}
+
+const scheduleFeedFetch = `-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET fetch_interval = $2,
+    next_fetch_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type ScheduleFeedFetchParams struct {
+	ID            uuid.UUID
+	FetchInterval int32
+}
+
+func (q *Queries) ScheduleFeedFetch(ctx context.Context, arg ScheduleFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeedFetch, arg.ID, arg.FetchInterval)
+	return err
+}
+
+const setFeedCacheHeaders = `-- name: SetFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type SetFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) SetFeedCacheHeaders(ctx context.Context, arg SetFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const setFeedExtractContent = `-- name: SetFeedExtractContent :execrows
+UPDATE feeds
+SET extract_content = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE url = $1 OR name = $1
+`
+
+type SetFeedExtractContentParams struct {
+	Url            sql.NullString
+	ExtractContent bool
+}
+
+func (q *Queries) SetFeedExtractContent(ctx context.Context, arg SetFeedExtractContentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedExtractContent, arg.Url, arg.ExtractContent)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setLegacyPostHash = `-- name: SetLegacyPostHash :exec
+UPDATE posts
+SET content_hash = $3
+WHERE feed_id = $1 AND guid = $2 AND content_hash = ''
+`
+
+type SetLegacyPostHashParams struct {
+	FeedID      uuid.UUID
+	Guid        string
+	ContentHash string
+}
+
+func (q *Queries) SetLegacyPostHash(ctx context.Context, arg SetLegacyPostHashParams) error {
+	_, err := q.db.ExecContext(ctx, setLegacyPostHash, arg.FeedID, arg.Guid, arg.ContentHash)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    description = $3,
+    site_link = $4,
+    image_url = $5,
+    language = $6,
+    generator = $7,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	Description sql.NullString
+	SiteLink    sql.NullString
+	ImageUrl    sql.NullString
+	Language    sql.NullString
+	Generator   sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.SiteLink,
+		arg.ImageUrl,
+		arg.Language,
+		arg.Generator,
+	)
+	return err
+}
+
+const updateFeedUrl = `-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET url = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdateFeedUrlParams struct {
+	ID  uuid.UUID
+	Url sql.NullString
+}
+
+func (q *Queries) UpdateFeedUrl(ctx context.Context, arg UpdateFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedUrl, arg.ID, arg.Url)
+	return err
+}
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type UpdatePostContentParams struct {
+	ID      uuid.UUID
+	Content string
+}
+
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent, arg.ID, arg.Content)
+	return err
+}
@@ -0,0 +1,198 @@
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Layouts tried one by one after the date string is normalized: weekday removed, commas removed,
+// month names translated into English abbreviations and time zone turned into numeric offset.
+var layouts = []string{
+	// RFC 822 / RFC 1123 family used by RSS pubDate
+	"2 Jan 2006 15:04:05 -0700",   // "Mon, 02 Jan 2006 15:04:05 GMT", "2 Jan 2006 15:04:05 +0100"
+	"2 Jan 2006 15:04:05 -07:00",  // "Mon, 02 Jan 2006 15:04:05 +01:00"
+	"2 Jan 2006 15:04:05",         // "Mon, 02 Jan 2006 15:04:05"
+	"2 Jan 2006 15:04 -0700",      // "Mon, 2 Jan 2006 15:04 EST"
+	"2 Jan 2006 15:04",            // "02 Jan 2006 15:04"
+	"2 Jan 06 15:04:05 -0700",     // "Mon, 02 Jan 06 15:04:05 GMT" (RFC 822 two-digit year)
+	"2 Jan 06 15:04 -0700",        // "02 Jan 06 15:04 -0700"
+	"2 Jan 06 15:04:05",           // "02 Jan 06 15:04:05"
+	"2 Jan 2006 3:04:05 PM -0700", // "2 Jan 2006 3:04:05 PM +0000"
+	"2 Jan 2006 3:04 PM",          // "2 January 2006 3:04 PM"
+	"2 Jan 2006",                  // "2 January 2006", "12 stycznia 2024"
+	"Jan 2 2006 15:04:05 -0700",   // "January 2, 2006 15:04:05 UTC"
+	"Jan 2 2006 15:04:05",         // "Jan 2, 2006 15:04:05"
+	"Jan 2 2006 15:04 -0700",      // "Jan 2, 2006 15:04 +0200"
+	"Jan 2 2006 15:04",            // "Jan 2, 2006 15:04"
+	"Jan 2 2006 3:04:05 PM -0700", // "Jan 2, 2006 3:04:05 PM EST"
+	"Jan 2 2006 3:04:05 PM",       // "Jan 2, 2006 3:04:05 PM"
+	"Jan 2 2006 3:04 PM -0700",    // "January 2, 2006 at 3:04 PM PST" ("at" is removed)
+	"Jan 2 2006 3:04 PM",          // "Jan 2, 2006 3:04 PM"
+	"Jan 2 2006",                  // "January 2, 2006"
+	"Jan 2 15:04:05 -0700 2006",   // "Mon Jan 2 15:04:05 MST 2006" (Unix date)
+	"Jan 2 15:04:05 2006",         // "Mon Jan  2 15:04:05 2006" (ANSI C)
+	"2006-01-02T15:04:05Z07:00",   // "2006-01-02T15:04:05Z", "2006-01-02T15:04:05.999+01:00"
+	"2006-01-02T15:04:05-0700",    // "2006-01-02T15:04:05+0100"
+	"2006-01-02T15:04:05",         // "2006-01-02T15:04:05"
+	"2006-01-02T15:04Z07:00",      // "2006-01-02T15:04+01:00"
+	"2006-01-02T15:04",            // "2006-01-02T15:04"
+	"2006-01-02 15:04:05 -0700",   // "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05 UTC"
+	"2006-01-02 15:04:05 -07:00",  // "2006-01-02 15:04:05 +01:00"
+	"2006-01-02 15:04:05Z07:00",   // "2006-01-02 15:04:05+01:00"
+	"2006-01-02 15:04:05-0700",    // "2006-01-02 15:04:05+0100"
+	"2006-01-02 15:04:05",         // "2006-01-02 15:04:05"
+	"2006-01-02 15:04 -0700",      // "2006-01-02 15:04 +0000"
+	"2006-01-02 15:04",            // "2006-01-02 15:04"
+	"2006-01-02",                  // "2006-01-02"
+	"2006/1/2 15:04:05",           // "2006/01/02 15:04:05"
+	"2006/1/2 15:04",              // "2006/01/02 15:04"
+	"2006/1/2",                    // "2006/01/02"
+	"2.1.2006 15:04:05",           // "02.01.2006 15:04:05"
+	"2.1.2006 15:04",              // "2.1.2006 15:04"
+	"2.1.2006",                    // "02.01.2006"
+	"20060102T150405Z0700",        // "20060102T150405Z" (iCalendar style)
+	"20060102",                    // "20060102"
+}
+
+// months maps English and localized month names (lowercase, without dot) into English abbreviations
+var months = map[string]string{}
+
+// weekdays are English and localized day names (lowercase, without dot) removed from the beginning
+var weekdays = map[string]bool{}
+
+func init() {
+	names := map[string][]string{
+		"Jan": {"january", "jan", "styczeń", "styczen", "stycznia", "sty", "januar", "jänner", "janvier", "janv", "enero", "ene", "gennaio", "gen", "januari", "janeiro"},
+		"Feb": {"february", "feb", "luty", "lutego", "lut", "februar", "février", "fevrier", "févr", "fevr", "fév", "febrero", "febbraio", "februari", "fevereiro", "fev"},
+		"Mar": {"march", "mar", "marzec", "marca", "märz", "maerz", "mär", "mrz", "mars", "marzo", "maart", "mrt", "março", "marco"},
+		"Apr": {"april", "apr", "kwiecień", "kwiecien", "kwietnia", "kwi", "avril", "avr", "abril", "abr", "aprile"},
+		"May": {"may", "maj", "maja", "mai", "mayo", "maggio", "mag", "mei", "maio"},
+		"Jun": {"june", "jun", "czerwiec", "czerwca", "cze", "juni", "juin", "junio", "giugno", "giu", "junho"},
+		"Jul": {"july", "jul", "lipiec", "lipca", "lip", "juli", "juillet", "juil", "julio", "luglio", "lug", "julho"},
+		"Aug": {"august", "aug", "sierpień", "sierpien", "sierpnia", "sie", "août", "aout", "agosto", "ago", "augustus"},
+		"Sep": {"september", "sep", "sept", "wrzesień", "wrzesien", "września", "wrzesnia", "wrz", "septembre", "septiembre", "setiembre", "settembre", "set", "setembro"},
+		"Oct": {"october", "oct", "październik", "pazdziernik", "października", "pazdziernika", "paź", "paz", "oktober", "okt", "octobre", "octubre", "ottobre", "ott", "outubro", "out"},
+		"Nov": {"november", "nov", "listopad", "listopada", "lis", "novembre", "noviembre", "novembro"},
+		"Dec": {"december", "dec", "grudzień", "grudzien", "grudnia", "gru", "dezember", "dez", "décembre", "decembre", "déc", "diciembre", "dic", "dicembre", "dezembro"},
+	}
+	for abbr, list := range names {
+		for _, name := range list {
+			months[name] = abbr
+		}
+	}
+	for _, day := range []string{
+		"monday", "mon", "tuesday", "tue", "tues", "wednesday", "wed", "thursday", "thu", "thur", "thurs",
+		"friday", "fri", "saturday", "sat", "sunday", "sun",
+		"poniedziałek", "poniedzialek", "pon", "wtorek", "wt", "środa", "sroda", "śr", "sr", "czwartek", "czw",
+		"piątek", "piatek", "pt", "sobota", "sob", "niedziela", "niedz", "nie", "ndz",
+		"montag", "mo", "dienstag", "di", "mittwoch", "mi", "donnerstag", "do", "freitag", "fr", "samstag", "sa", "sonntag", "so",
+		"lundi", "lun", "mardi", "mercredi", "mer", "jeudi", "jeu", "vendredi", "ven", "samedi", "sam", "dimanche", "dim",
+		"lunes", "martes", "miércoles", "miercoles", "mié", "jueves", "jue", "viernes", "vie", "sábado", "sabado", "sáb", "domingo", "dom",
+	} {
+		weekdays[day] = true
+	}
+}
+
+// Offsets of time zone abbreviations seen in feeds. Go would parse unknown abbreviations as UTC,
+// so they are replaced by numeric offsets before parsing.
+var zones = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000", "WET": "+0000",
+	"BST": "+0100", "WEST": "+0100", "CET": "+0100", "MET": "+0100", "CEST": "+0200", "MEST": "+0200",
+	"EET": "+0200", "EEST": "+0300", "MSK": "+0300", "IST": "+0530", "SGT": "+0800", "HKT": "+0800",
+	"JST": "+0900", "KST": "+0900", "AEST": "+1000", "AEDT": "+1100", "NZST": "+1200", "NZDT": "+1300",
+	"EST": "-0500", "EDT": "-0400", "CST": "-0600", "CDT": "-0500", "MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700", "AKST": "-0900", "AKDT": "-0800", "HST": "-1000",
+}
+
+var (
+	// "GMT+0100", "UTC+01:00", "GMT-5"
+	zoneOffsetPattern = regexp.MustCompile(`(?i)\b(?:GMT|UTC|UT)\s*([+-])(\d{1,2}):?(\d{2})?\b`)
+	// "(PST)" after numeric offset
+	parenthesesPattern = regexp.MustCompile(`\([^)]*\)`)
+	// ordinal suffixes: "1st", "2nd", "3rd", "4th" and day with dot: "12. März 2024"
+	ordinalPattern = regexp.MustCompile(`\b(\d{1,2})(?:(?:st|nd|rd|th)\b|\.(?:\s|$))`)
+)
+
+// Parse reads date in any of the formats found in RSS, Atom, RDF and JSON feeds.
+// Date without time zone is treated as UTC, result is always returned in UTC.
+func Parse(value string) (time.Time, error) {
+	normalized := normalize(value)
+	if normalized == "" {
+		return time.Time{}, fmt.Errorf("could not parse date: empty string")
+	}
+	if t, ok := parseLayouts(normalized); ok {
+		return t, nil
+	}
+	// unknown time zone abbreviation at the end, e.g. "CAT", is dropped and UTC is assumed
+	fields := strings.Fields(normalized)
+	if last := fields[len(fields)-1]; len(fields) > 1 && isLetters(last) {
+		if t, ok := parseLayouts(strings.Join(fields[:len(fields)-1], " ")); ok {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse date: %s", value)
+}
+
+func parseLayouts(value string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalize turns date string into a form matching one of layouts
+func normalize(value string) string {
+	value = strings.TrimSpace(value)
+	value = parenthesesPattern.ReplaceAllString(value, " ")
+	value = zoneOffsetPattern.ReplaceAllStringFunc(value, func(zone string) string {
+		parts := zoneOffsetPattern.FindStringSubmatch(zone)
+		hours, minutes := parts[2], parts[3]
+		if len(hours) == 1 {
+			hours = "0" + hours
+		}
+		if minutes == "" {
+			minutes = "00"
+		}
+		return " " + parts[1] + hours + minutes
+	})
+	value = ordinalPattern.ReplaceAllString(value, "$1 ")
+	value = strings.ReplaceAll(value, ",", " ")
+
+	fields := strings.Fields(value)
+	var result []string
+	for i, field := range fields {
+		lower := strings.ToLower(strings.TrimSuffix(field, "."))
+		switch {
+		case i == 0 && weekdays[lower] && months[lower] == "" && len(fields) > 1:
+			// weekday does not change the date, it is skipped
+		case lower == "at" || lower == "o" || lower == "um" || lower == "à" || lower == "de" || lower == "r":
+			// "January 2, 2006 at 3:04 PM", "2 de enero de 2024", "2 stycznia 2024 r."
+		case months[lower] != "" && !isClock(field):
+			result = append(result, months[lower])
+		case zones[strings.ToUpper(field)] != "" && i > 0:
+			result = append(result, zones[strings.ToUpper(field)])
+		case strings.EqualFold(field, "am") || strings.EqualFold(field, "pm"):
+			result = append(result, strings.ToUpper(field))
+		default:
+			result = append(result, field)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isClock(s string) bool {
+	return strings.Contains(s, ":")
+}
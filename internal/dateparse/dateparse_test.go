@@ -0,0 +1,115 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// RFC 822 / RFC 1123
+		{"Mon, 02 Jan 2006 15:04:05 GMT", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 +0000", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 +0100", utc(2006, 1, 2, 14, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 +01:00", utc(2006, 1, 2, 14, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 -0500", utc(2006, 1, 2, 20, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 EST", utc(2006, 1, 2, 20, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 PDT", utc(2006, 1, 2, 22, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 CEST", utc(2006, 1, 2, 13, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 UT", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 Z", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05", utc(2006, 1, 2, 15, 4, 5)},
+		{"02 Jan 2006 15:04:05 GMT", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04 GMT", utc(2006, 1, 2, 15, 4, 0)},
+		{"Monday, 02 January 2006 15:04:05 GMT", utc(2006, 1, 2, 15, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 CAT", utc(2006, 1, 2, 15, 4, 5)},
+		// RFC 822 two-digit years
+		{"Mon, 02 Jan 06 15:04:05 GMT", utc(2006, 1, 2, 15, 4, 5)},
+		{"02 Jan 06 15:04 -0700", utc(2006, 1, 2, 22, 4, 0)},
+		{"Thu, 01 Jan 70 00:00:00 +0000", utc(1970, 1, 1, 0, 0, 0)},
+		// single-digit days and hours
+		{"Mon, 2 Jan 2006 15:04:05 GMT", utc(2006, 1, 2, 15, 4, 5)},
+		{"Sun, 5 Mar 2023 9:30:00 +0000", utc(2023, 3, 5, 9, 30, 0)},
+		{"5 Mar 2023", utc(2023, 3, 5, 0, 0, 0)},
+		// GMT+hhmm and similar offsets
+		{"Mon, 02 Jan 2006 15:04:05 GMT+0100", utc(2006, 1, 2, 14, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 GMT+01:00", utc(2006, 1, 2, 14, 4, 5)},
+		{"Mon, 02 Jan 2006 15:04:05 UTC-5", utc(2006, 1, 2, 20, 4, 5)},
+		// trailing zone name in parentheses
+		{"Mon, 02 Jan 2006 15:04:05 -0800 (PST)", utc(2006, 1, 2, 23, 4, 5)},
+		{"Tue, 10 Oct 2023 08:00:00 +0200 (Central European Summer Time)", utc(2023, 10, 10, 6, 0, 0)},
+		// ISO 8601 / RFC 3339
+		{"2006-01-02T15:04:05Z", utc(2006, 1, 2, 15, 4, 5)},
+		{"2006-01-02T15:04:05+01:00", utc(2006, 1, 2, 14, 4, 5)},
+		{"2006-01-02T15:04:05.999+01:00", time.Date(2006, 1, 2, 14, 4, 5, 999000000, time.UTC)},
+		{"2006-01-02T15:04:05+0100", utc(2006, 1, 2, 14, 4, 5)},
+		{"2006-01-02T15:04:05", utc(2006, 1, 2, 15, 4, 5)},
+		{"2006-01-02T15:04+01:00", utc(2006, 1, 2, 14, 4, 0)},
+		{"2006-01-02 15:04:05", utc(2006, 1, 2, 15, 4, 5)},
+		{"2006-01-02 15:04:05 UTC", utc(2006, 1, 2, 15, 4, 5)},
+		{"2006-01-02 15:04:05 +01:00", utc(2006, 1, 2, 14, 4, 5)},
+		{"2006-01-02 15:04", utc(2006, 1, 2, 15, 4, 0)},
+		// date only
+		{"2006-01-02", utc(2006, 1, 2, 0, 0, 0)},
+		{"2024/03/15", utc(2024, 3, 15, 0, 0, 0)},
+		{"15.03.2024", utc(2024, 3, 15, 0, 0, 0)},
+		{"20240315", utc(2024, 3, 15, 0, 0, 0)},
+		{"20240315T101500Z", utc(2024, 3, 15, 10, 15, 0)},
+		// month first, as written in English
+		{"January 2, 2006", utc(2006, 1, 2, 0, 0, 0)},
+		{"Jan 2, 2006 3:04 PM", utc(2006, 1, 2, 15, 4, 0)},
+		{"January 2, 2006 at 3:04 PM PST", utc(2006, 1, 2, 23, 4, 0)},
+		{"March 1st, 2024", utc(2024, 3, 1, 0, 0, 0)},
+		{"Mon Jan 2 15:04:05 MST 2006", utc(2006, 1, 2, 22, 4, 5)},
+		{"Mon Jan  2 15:04:05 2006", utc(2006, 1, 2, 15, 4, 5)},
+		// localized month and weekday names
+		{"12 stycznia 2024", utc(2024, 1, 12, 0, 0, 0)},
+		{"12 stycznia 2024 r.", utc(2024, 1, 12, 0, 0, 0)},
+		{"pon., 12 lut 2024 10:00:00 +0100", utc(2024, 2, 12, 9, 0, 0)},
+		{"Śr, 3 września 2025 08:15:00 GMT", utc(2025, 9, 3, 8, 15, 0)},
+		{"12. März 2024 10:00", utc(2024, 3, 12, 10, 0, 0)},
+		{"Di, 05 Dez 2023 18:30:00 +0100", utc(2023, 12, 5, 17, 30, 0)},
+		{"mardi 5 décembre 2023", utc(2023, 12, 5, 0, 0, 0)},
+		{"2 de enero de 2024", utc(2024, 1, 2, 0, 0, 0)},
+		{"12 gennaio 2024 14:00", utc(2024, 1, 12, 14, 0, 0)},
+		// surrounding whitespace
+		{"  Mon, 02 Jan 2006 15:04:05 GMT\n", utc(2006, 1, 2, 15, 4, 5)},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if !got.Equal(test.want) || got.Location() != time.UTC {
+			t.Errorf("Parse(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"not a date",
+		"yesterday",
+		"Mon, 29 Feb 2021 10:00:00 GMT",
+		"Mon, 32 Jan 2006 15:04:05 GMT",
+		"Mon, 02 Foo 2006 15:04:05 GMT",
+		"2006-13-02",
+		"2006-02-30T10:00:00Z",
+		"Mon, 02 Jan 2006 25:04:05 GMT",
+		"12 stycznia",
+	}
+	for _, input := range tests {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article is the main content found in a web page
+type Article struct {
+	Title string
+	HTML  string
+	Text  string
+}
+
+// ErrNoArticle is returned when page has no block of text which looks like an article
+var ErrNoArticle = errors.New("no article content found")
+
+// minArticleLength is the shortest text (in characters) accepted as an article
+const minArticleLength = 250
+
+// Elements which never belong to article content
+var removedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Link:     true,
+	atom.Meta:     true,
+}
+
+// Class and id patterns (the same idea as in Readability) telling if element is boilerplate or content
+var (
+	unlikelyPattern = regexp.MustCompile(`(?i)ad-|ads|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|footer|menu|modal|nav|newsletter|pager|popup|promo|related|remark|share|sidebar|social|sponsor|subscribe|tags|widget`)
+	positivePattern = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+)
+
+// Elements scored as paragraphs of text
+var paragraphTags = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Td:         true,
+	atom.Blockquote: true,
+}
+
+// FromHTML finds main article of the page and returns it without navigation, ads and comments
+func FromHTML(r io.Reader) (Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Article{}, err
+	}
+	article := Article{Title: pageTitle(doc)}
+	removeBoilerplate(doc)
+
+	scores := map[*html.Node]float64{}
+	for _, p := range findAll(doc, func(n *html.Node) bool { return paragraphTags[n.DataAtom] }) {
+		text := strings.TrimSpace(textOf(p))
+		if len(text) < 25 || p.Parent == nil {
+			continue
+		}
+		// longer paragraphs with more commas look more like real text
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		addScore(scores, p.Parent, score)
+		if p.Parent.Parent != nil {
+			addScore(scores, p.Parent.Parent, score/2)
+		}
+	}
+
+	var top *html.Node
+	var topScore float64
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		scores[node] = score
+		if top == nil || score > topScore {
+			top, topScore = node, score
+		}
+	}
+	if top == nil {
+		return Article{}, ErrNoArticle
+	}
+
+	// siblings with good score (e.g. second part of article split by an image) are kept too
+	var parts []*html.Node
+	if top.Parent == nil {
+		parts = []*html.Node{top}
+	} else {
+		threshold := max(10, topScore*0.2)
+		for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if sibling == top || scores[sibling] >= threshold {
+				parts = append(parts, sibling)
+			}
+		}
+	}
+
+	var htmlBuf bytes.Buffer
+	var textParts []string
+	for _, part := range parts {
+		if err := html.Render(&htmlBuf, part); err != nil {
+			return Article{}, err
+		}
+		if text := collapseSpaces(textOf(part)); text != "" {
+			textParts = append(textParts, text)
+		}
+	}
+	article.HTML = htmlBuf.String()
+	article.Text = strings.Join(textParts, "\n\n")
+	if len(article.Text) < minArticleLength {
+		return Article{}, ErrNoArticle
+	}
+	return article, nil
+}
+
+// removeBoilerplate deletes elements which are not a part of article
+func removeBoilerplate(doc *html.Node) {
+	toRemove := findAll(doc, func(n *html.Node) bool {
+		if n.Type == html.CommentNode {
+			return true
+		}
+		if n.Type != html.ElementNode {
+			return false
+		}
+		if removedTags[n.DataAtom] {
+			return true
+		}
+		if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			return false
+		}
+		match := attr(n, "class") + " " + attr(n, "id")
+		return unlikelyPattern.MatchString(match) && !positivePattern.MatchString(match)
+	})
+	for _, n := range toRemove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// addScore adds score of the paragraph to its container, first time container gets bonus from its class and id
+func addScore(scores map[*html.Node]float64, node *html.Node, score float64) {
+	if _, ok := scores[node]; !ok {
+		scores[node] = classWeight(node)
+	}
+	scores[node] += score
+}
+
+func classWeight(node *html.Node) float64 {
+	weight := 0.0
+	if node.DataAtom == atom.Article || node.DataAtom == atom.Main {
+		weight += 25
+	}
+	for _, name := range []string{"class", "id"} {
+		value := attr(node, name)
+		if value == "" {
+			continue
+		}
+		if positivePattern.MatchString(value) {
+			weight += 25
+		}
+		if unlikelyPattern.MatchString(value) {
+			weight -= 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is part of the text which is inside links, menus and lists of links have it close to 1
+func linkDensity(node *html.Node) float64 {
+	textLength := len(textOf(node))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	for _, a := range findAll(node, func(n *html.Node) bool { return n.DataAtom == atom.A }) {
+		linkLength += len(textOf(a))
+	}
+	return float64(linkLength) / float64(textLength)
+}
+
+func pageTitle(doc *html.Node) string {
+	titles := findAll(doc, func(n *html.Node) bool { return n.DataAtom == atom.Title })
+	if len(titles) == 0 {
+		return ""
+	}
+	return collapseSpaces(textOf(titles[0]))
+}
+
+// findAll returns all nodes under root (root included) accepted by match
+func findAll(root *html.Node, match func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if match(n) {
+			found = append(found, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return found
+}
+
+func textOf(node *html.Node) string {
+	var sb strings.Builder
+	for _, n := range findAll(node, func(n *html.Node) bool { return n.Type == html.TextNode }) {
+		sb.WriteString(n.Data)
+		sb.WriteString(" ")
+	}
+	return sb.String()
+}
+
+func attr(node *html.Node, name string) string {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
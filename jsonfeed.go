@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+)
+
+// jsonFeedVersionPrefix starts version of every JSON Feed, e.g. "https://jsonfeed.org/version/1.1",
+// other JSON documents (API responses) do not have it
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+// ******** START:  Struct for JSON Feed 1.1 *********
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Icon        string         `json:"icon"`
+	Favicon     string         `json:"favicon"`
+	Language    string         `json:"language"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []JSONFeedAuthor `json:"authors"`
+	Author        *JSONFeedAuthor  `json:"author"` // JSON Feed 1.0, replaced by authors in 1.1
+	Tags          []string         `json:"tags"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// ******** END:  Struct for JSON Feed 1.1 *********
+
+// isJSONFeed decides if response is JSON Feed by Content-Type or, when the server
+// sends something generic, by looking at the first character of the body
+func isJSONFeed(data []byte, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "application/feed+json") || strings.Contains(contentType, "application/json") {
+		return true
+	}
+	trimmed := strings.TrimSpace(string(data))
+	return strings.HasPrefix(trimmed, "{")
+}
+
+// toRSS falls back to external_url (or id looking like a link) for items without url,
+// and to the single author of JSON Feed 1.0 when authors are not given
+func (f *JSONFeed) toRSS() *RSS {
+	rss := &RSS{
+		Channel: Channel{
+			Title:       f.Title,
+			Link:        f.HomePageURL,
+			Description: f.Description,
+			Language:    f.Language,
+		},
+	}
+	if image := f.Icon; image != "" || f.Favicon != "" {
+		if image == "" {
+			image = f.Favicon
+		}
+		rss.Channel.Images = []RSSImage{{URL: image}}
+	}
+	for _, item := range f.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+		if link == "" && strings.HasPrefix(item.ID, "http") {
+			link = item.ID
+		}
+		content := item.ContentHTML
+		if content == "" {
+			content = item.ContentText
+		}
+		description := item.Summary
+		if description == "" {
+			description = content
+		}
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []JSONFeedAuthor{*item.Author}
+		}
+		var names []string
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+		rss.Channel.Items = append(rss.Channel.Items, Item{
+			Title:       item.Title,
+			Link:        strings.TrimSpace(link),
+			Description: description,
+			Content:     content,
+			GUID:        item.ID,
+			Author:      strings.Join(names, ", "),
+			Categories:  item.Tags,
+			PubDate:     strings.TrimSpace(pubDate),
+		})
+	}
+	return rss
+}
@@ -1,37 +1,58 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Geralt28/gator/internal/config"
 	"github.com/Geralt28/gator/internal/database"
+	"github.com/Geralt28/gator/internal/dateparse"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
-// List of common RSS datetime formats
-var rssDateFormats = []string{
-	time.RFC1123,                // "Mon, 02 Jan 2006 15:04:05 MST"
-	time.RFC1123Z,               // "Mon, 02 Jan 2006 15:04:05 -0700"
-	time.RFC3339,                // "2006-01-02T15:04:05Z"
-	"02 Jan 2006 15:04:05 MST",  // "02 Jan 2006 15:04:05 UTC"
-	"2006-01-02 15:04:05 -0700", // "2006-01-02 15:04:05 -0700"
-	"2006-01-02T15:04:05-0700",  // "2006-01-02T15:04:05-0700"
-}
+const (
+	// defaultAggInterval is time between requests of agg, when not given as argument or in config
+	defaultAggInterval = "1m0s"
+	// minAggInterval protects feeds from being fetched too often
+	minAggInterval = 10 * time.Second
+	// maxPostRevisions is number of previous versions kept for every post
+	maxPostRevisions = 5
+	// defaultAggConcurrency is number of feeds fetched in parallel by agg
+	defaultAggConcurrency = 4
+	// defaultHostDelay is the shortest time between two requests of agg to one host
+	defaultHostDelay = 2 * time.Second
+	// defaultAggRPS is number of requests per second agg sends to all hosts together
+	defaultAggRPS = 5
+	// feedTimeout limits time of fetching one feed, longer http_timeout of the config raises it (httpClient.fetchTimeout)
+	feedTimeout = 30 * time.Second
+	// saveTimeout limits time of saving one fetched feed into database
+	saveTimeout = 30 * time.Second
+)
 
 type state struct {
 	db     *database.Queries
+	sqlDB  *sql.DB // for transactions, queries go through db
 	config *config.Config
+	client *httpClient
 }
 
 type command struct {
@@ -45,23 +66,59 @@ type commands struct {
 
 // ******** START:  Struct for RSS feed *********
 
+// RSS is also the model of every other format: Atom, RDF and JSON Feed are converted into it
+// by their toRSS methods, so scrapeFeeds stores all feeds the same way
 type RSS struct {
-	XMLName xml.Name `xml:"rss"`
-	Channel Channel  `xml:"channel"`
+	XMLName xml.Name      `xml:"rss"`
+	Channel Channel       `xml:"channel"`
+	MaxAge  time.Duration `xml:"-"` // Cache-Control max-age of the response
+	MovedTo string        `xml:"-"` // new url of the feed, if it was permanently redirected
 }
 
 type Channel struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	Items       []Item `xml:"item"`
+	Title           string     `xml:"title"`
+	Link            string     `xml:"-"`    // set by parseFeed from Links
+	Links           []RSSLink  `xml:"link"` // <link> and also <atom:link rel="self"/> of many feeds
+	Description     string     `xml:"description"`
+	TTL             string     `xml:"ttl"`
+	UpdatePeriod    string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	Language        string     `xml:"language"`
+	Generator       string     `xml:"generator"`
+	Images          []RSSImage `xml:"image"` // <image><url> of RSS, itunes:image has href attribute
+	Items           []Item     `xml:"item"`
+}
+
+type RSSLink struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+// rssLink returns text of <link> without namespace, atom:link has no text and would hide the site url
+func rssLink(links []RSSLink) string {
+	for _, link := range links {
+		if link.XMLName.Space == "" {
+			return strings.TrimSpace(link.Text)
+		}
+	}
+	return ""
+}
+
+type RSSImage struct {
+	URL  string `xml:"url"`
+	Href string `xml:"href,attr"`
 }
 
 type Item struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	GUID        string   `xml:"guid"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
 }
 
 // ******** END:  Struct for RSS feed *********
@@ -145,17 +202,80 @@ func handlerUsers(s *state, cmd command) error {
 }
 
 func handlerAgg(s *state, cmd command, time_between_reqs string) error {
-
-	fmt.Println("Collecting feeds every", time_between_reqs)
-	timeBetweenRequests, err := time.ParseDuration(time_between_reqs)
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	concurrency := flags.Int("concurrency", defaultAggConcurrency, "number of feeds fetched in parallel")
+	once := flags.Bool("once", false, "run one scrape cycle, print report and exit")
+	hostDelay := flags.Duration("host-delay", defaultHostDelay, "shortest time between requests to one host")
+	rps := flags.Float64("rps", defaultAggRPS, "requests per second to all hosts together")
+	var onlyFeeds []string
+	flags.Func("feed", "with --once: fetch only feed with this name or url (can be repeated)", func(value string) error {
+		onlyFeeds = append(onlyFeeds, value)
+		return nil
+	})
+	args, err := parseFlags(flags, cmd.arguments)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("error: agg expects at most one argument (time_between_reqs, e.g. 30s or 5m)")
+	}
+	if len(args) == 1 {
+		time_between_reqs = args[0]
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", *concurrency)
+	}
+	if *hostDelay < 0 {
+		return fmt.Errorf("host-delay can not be negative, got %s", *hostDelay)
+	}
+	if *rps <= 0 {
+		return fmt.Errorf("rps must be greater than 0, got %g", *rps)
+	}
+	if len(onlyFeeds) > 0 && !*once {
+		return fmt.Errorf("--feed can be used only together with --once")
+	}
+	timeBetweenRequests, err := parseAggInterval(time_between_reqs)
 	if err != nil {
-		fmt.Println("error: can not convert string into time duration")
+		return err
+	}
+
+	// Ctrl-C or systemd stop cancels ctx, so agg can finish current work and exit
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := aggOptions{
+		interval:    timeBetweenRequests,
+		concurrency: *concurrency,
+		feeds:       onlyFeeds,
+		limiter:     newHostLimiter(*hostDelay, *rps),
+	}
+	if *once {
+		return aggOnce(ctx, s, opts)
 	}
+
+	fmt.Println("Collecting feeds every", timeBetweenRequests, "using", *concurrency, "workers")
 	ticker := time.NewTicker(timeBetweenRequests)
-	for ; ; <-ticker.C {
+	defer ticker.Stop()
+	for {
 		fmt.Println("updating feeds...")
 		fmt.Println()
-		scrapeFeeds(s)
+		results, err := scrapeFeeds(ctx, s, opts)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("error: could not get feeds to fetch:", err)
+		}
+		// checked before select, because a tick which came during the cycle is ready too
+		// and select could start a new (empty) cycle with cancelled ctx
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+				continue
+			}
+		}
+		fmt.Println()
+		fmt.Println("agg stopped")
+		printScrapeSummary(results)
+		return nil
 	}
 
 	//url := "https://www.wagslane.dev/index.xml"
@@ -179,32 +299,187 @@ func handlerAgg(s *state, cmd command, time_between_reqs string) error {
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 2 {
-		return fmt.Errorf("error: addfeed expects exactly two arguments (name_of_feed, feed_url)")
+	if len(cmd.arguments) != 1 && len(cmd.arguments) != 2 {
+		return fmt.Errorf("error: addfeed expects feed_url and optional name_of_feed before it (addfeed [name_of_feed] feed_url)")
 	}
-	//rss, err := fetchFeed(ctx, cmd.arguments[0])
-	//if err != nil {
-	//	return err}
-	s.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Name:      cmd.arguments[0],
-		Url:       sql.NullString{String: cmd.arguments[1], Valid: true},
-		UserID:    user.ID,
+	pageURL := cmd.arguments[len(cmd.arguments)-1]
+	// user can give address of a website, then its feed is looked for
+	discoverCtx, cancel := context.WithTimeout(context.Background(), s.client.fetchTimeout())
+	candidates, err := discoverFeeds(discoverCtx, s.client, pageURL)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no feed found at: %s", pageURL)
+	}
+	chosen, err := chooseFeed(candidates)
+	if err != nil {
+		return err
+	}
+	feedURL := chosen.URL
+	if feedURL != pageURL {
+		fmt.Println("Using feed:", feedURL)
+	}
+	// feed is fetched before saving, so typos and pages which are not feeds are not stored
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.fetchTimeout())
+	defer cancel()
+	rss, _, err := fetchFeed(ctx, s.client, feedURL, feedCache{})
+	if err != nil {
+		return fmt.Errorf("%s is not a valid feed: %v", feedURL, err)
+	}
+	if rss.MovedTo != "" {
+		fmt.Println("Feed moved permanently to:", rss.MovedTo)
+		feedURL = rss.MovedTo
+	}
+	name := ""
+	if len(cmd.arguments) == 2 {
+		name = cmd.arguments[0]
+	}
+	if name == "" {
+		name = strings.TrimSpace(rss.Channel.Title)
+	}
+	if name == "" {
+		return fmt.Errorf("feed has no title, give its name: addfeed name_of_feed %s", feedURL)
+	}
+	description := strings.TrimSpace(rss.Channel.Description)
+	siteLink := strings.TrimSpace(rss.Channel.Link)
+	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		Name:        name,
+		Url:         sql.NullString{String: feedURL, Valid: true},
+		UserID:      user.ID,
+		Description: sql.NullString{String: description, Valid: description != ""},
+		SiteLink:    sql.NullString{String: siteLink, Valid: siteLink != ""},
 	})
+	if err != nil {
+		return fmt.Errorf("could not add feed %s: %v", feedURL, err)
+	}
+	saveFeedMetadata(context.Background(), s, feed.ID, rss, os.Stdout)
+	fmt.Println("Feed", name, "added!")
 
 	followCmd := command{
 		name:      "follow",
-		arguments: []string{cmd.arguments[1]}, // Pass only URL
+		arguments: []string{feedURL}, // Pass only URL
 	}
-	err := handlerFollow(s, followCmd, user)
+	err = handlerFollow(s, followCmd, user)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("error: import expects one argument: file.opml")
+	}
+	data, err := os.ReadFile(cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	var opml OPML
+	if err := newXMLDecoder(data).Decode(&opml); err != nil {
+		return fmt.Errorf("%s is not a valid OPML file: %v", cmd.arguments[0], err)
+	}
+	ctx := context.Background()
+	var added, existing, invalid, followed int
+	seen := map[string]bool{}
+	for _, entry := range opmlFeeds(opml.Body.Outlines, "") {
+		if entry.err != nil {
+			fmt.Println("Invalid:", entry.name, " | ", entry.err)
+			invalid++
+			continue
+		}
+		if seen[entry.url] {
+			existing++
+			continue
+		}
+		seen[entry.url] = true
+		url := sql.NullString{String: entry.url, Valid: true}
+		feed, err := s.db.GetFeedByUrl(ctx, url)
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      entry.name,
+				Url:       url,
+				UserID:    user.ID,
+			})
+			if err != nil {
+				fmt.Println("Invalid:", entry.name, " | ", "could not add feed:", err)
+				invalid++
+				continue
+			}
+			fmt.Println("Added:", entry.name, " | ", "URL:", entry.url)
+			added++
+		} else if err != nil {
+			return err
+		} else {
+			existing++
+		}
+		inserted, err := s.db.ImportFeedFollow(ctx, database.ImportFeedFollowParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Folder: sql.NullString{String: entry.folder, Valid: entry.folder != ""},
+		})
+		if err != nil {
+			fmt.Println("error: could not follow feed:", entry.url, err)
+			continue
+		}
+		if inserted {
+			followed++
+		}
+	}
+	fmt.Println()
+	fmt.Printf("%d added, %d already existing, %d invalid, %d newly followed\n", added, existing, invalid, followed)
+	return nil
+}
+
+func handlerExport(s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) < 1 || len(cmd.arguments) > 2 || cmd.arguments[0] != "opml" {
+		return fmt.Errorf("error: export expects format opml and optional file name (export opml [file])")
+	}
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	var feeds []opmlFeed
+	for _, follow := range follows {
+		feeds = append(feeds, opmlFeed{
+			name:     follow.Feedname,
+			url:      follow.Url.String,
+			folder:   follow.Folder.String,
+			siteLink: follow.SiteLink.String,
+		})
+	}
+	opml := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       "Gator feeds of " + user.Name,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+		Body: OPMLBody{Outlines: opmlOutlines(feeds)},
+	}
+	data, err := xml.MarshalIndent(opml, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	if len(cmd.arguments) == 1 {
+		fmt.Print(string(data))
+		return nil
+	}
+	if err := os.WriteFile(cmd.arguments[1], data, 0644); err != nil {
+		return err
+	}
+	fmt.Println("Exported", len(feeds), "feeds to", cmd.arguments[1])
+	return nil
+}
+
 func handlerFeeds(s *state, cmd command) error {
 	//if len(cmd.arguments) != 0 {
 	//return fmt.Errorf("error: feeds should not have any arguments")
@@ -219,6 +494,120 @@ func handlerFeeds(s *state, cmd command) error {
 	return nil
 }
 
+func handlerFeedStatus(s *state, cmd command) error {
+	if len(cmd.arguments) != 0 {
+		return fmt.Errorf("error: feedstatus should not have any arguments")
+	}
+	feeds, err := s.db.GetFeedsStatus(context.Background())
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, feed := range feeds {
+		health := feedHealth(feed.ConsecutiveFailures)
+		if !feed.Active {
+			health = feedInactive
+		}
+		counts[health]++
+		fmt.Println("Name:", feed.Name, " | ", "URL:", feed.Url.String, " | ", "Status:", health)
+		if feed.LastFetchedAt.Valid {
+			fmt.Println("  Last fetched:", feed.LastFetchedAt.Time)
+		}
+		if feed.NextFetchAt.Valid {
+			fmt.Println("  Next fetch:", feed.NextFetchAt.Time)
+		}
+		if feed.ConsecutiveFailures > 0 {
+			fmt.Println("  Failures in a row:", feed.ConsecutiveFailures)
+		}
+		if feed.LastError.Valid {
+			fmt.Println("  Last error:", feed.LastError.String, "at", feed.LastErrorAt.Time)
+		}
+		if feed.DeactivatedReason.Valid {
+			fmt.Println("  Deactivated:", feed.DeactivatedReason.String)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("%d healthy, %d degraded, %d dead, %d inactive\n", counts[feedHealthy], counts[feedDegraded], counts[feedDead], counts[feedInactive])
+	return nil
+}
+
+func handlerFeed(s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("error: feed expects one argument: feed name or url")
+	}
+	feed, err := s.db.GetFeedDetails(context.Background(), cmd.arguments[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no feed with name or url: %s", cmd.arguments[0])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Name:", feed.Name)
+	fmt.Println("URL:", feed.Url.String)
+	if feed.Title.Valid {
+		fmt.Println("Title:", feed.Title.String)
+	}
+	if feed.Description.Valid {
+		fmt.Println("Description:", feed.Description.String)
+	}
+	if feed.SiteLink.Valid {
+		fmt.Println("Website:", feed.SiteLink.String)
+	}
+	if feed.ImageUrl.Valid {
+		fmt.Println("Image:", feed.ImageUrl.String)
+	}
+	if feed.Language.Valid {
+		fmt.Println("Language:", feed.Language.String)
+	}
+	if feed.Generator.Valid {
+		fmt.Println("Generator:", feed.Generator.String)
+	}
+	fmt.Println("Added by:", feed.UserName.String, "at", feed.CreatedAt)
+	fmt.Println("Posts:", feed.PostCount, " | ", "Followers:", feed.FollowerCount)
+	health := feedHealth(feed.ConsecutiveFailures)
+	if !feed.Active {
+		health = feedInactive
+	}
+	fmt.Println("Status:", health, " | ", "Full text:", feed.ExtractContent)
+	if feed.DeactivatedReason.Valid {
+		fmt.Println("Deactivated:", feed.DeactivatedReason.String)
+	}
+	if feed.LastFetchedAt.Valid {
+		fmt.Println("Last fetched:", feed.LastFetchedAt.Time)
+	}
+	if feed.NextFetchAt.Valid {
+		fmt.Println("Next fetch:", feed.NextFetchAt.Time)
+	}
+	if feed.LastError.Valid {
+		fmt.Println("Last error:", feed.LastError.String, "at", feed.LastErrorAt.Time)
+	}
+	return nil
+}
+
+func handlerFullText(s *state, cmd command) error {
+	if len(cmd.arguments) != 2 || (cmd.arguments[1] != "on" && cmd.arguments[1] != "off") {
+		return fmt.Errorf("error: fulltext expects two arguments: feed name or url, on|off")
+	}
+	feed := cmd.arguments[0]
+	enabled := cmd.arguments[1] == "on"
+	updated, err := s.db.SetFeedExtractContent(context.Background(), database.SetFeedExtractContentParams{
+		Url:            sql.NullString{String: feed, Valid: true},
+		ExtractContent: enabled,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("no feed with name or url: %s", feed)
+	}
+	if enabled {
+		fmt.Println("Full articles will be downloaded for new posts of feed:", feed)
+	} else {
+		fmt.Println("Posts of feed", feed, "will keep content from the feed")
+	}
+	return nil
+}
+
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.arguments) != 1 {
 		return fmt.Errorf("error: follow should have only one argument: url")
@@ -313,16 +702,46 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
-// Function more to train then necessary. Not sure why it is said Agg funcion need to take parameter and not to set as constant
+// middlewareAgg gives agg the default time between requests: fetch_interval from config or 1 minute.
+// Argument of agg command overrides it.
 func middlewareAgg(handler func(s *state, cmd command, time_between_reqs string) error) func(s *state, cmd command) error {
 	return func(s *state, cmd command) error {
-		// Set 1 minute as a string parameter
-		time_between_reqs := "1m0s"
-		// Call the actual handler, passing the user along
+		time_between_reqs := s.config.Fetch_interval
+		if time_between_reqs == "" {
+			time_between_reqs = defaultAggInterval
+		}
+		// Call the actual handler, passing the interval along
 		return handler(s, cmd, time_between_reqs)
 	}
 }
 
+// parseAggInterval converts string into duration and checks it is not shorter than minAggInterval
+func parseAggInterval(time_between_reqs string) (time.Duration, error) {
+	interval, err := time.ParseDuration(time_between_reqs)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time between requests %q (use e.g. 30s, 5m, 1h): %v", time_between_reqs, err)
+	}
+	if interval < minAggInterval {
+		return 0, fmt.Errorf("time between requests %s is too short, minimum is %s", interval, minAggInterval)
+	}
+	return interval, nil
+}
+
+// parseFlags parses flags placed anywhere between arguments and returns the remaining positional arguments
+func parseFlags(flags *flag.FlagSet, arguments []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(arguments); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		arguments = flags.Args()[1:]
+	}
+}
+
 func (c *commands) register(name string, f func(*state, command) error) {
 	//rejestruje fukcje pod nazwa "name" jako klucz i funcje f, ktora bedzie obslugiwala komende
 	c.komendy[name] = f
@@ -338,96 +757,706 @@ func (c *commands) run(s *state, cmd command) error {
 	return handler(s, cmd)
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSS, error) {
-	var rss RSS
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// errFeedNotModified is returned by fetchFeed when server answers 304 Not Modified
+var errFeedNotModified = errors.New("feed not modified")
+
+// errFeedGone is returned by fetchFeed when server answers 410 Gone, such feed is deactivated
+var errFeedGone = errors.New("feed is gone (410)")
+
+// feedCache keeps ETag and Last-Modified of the last response, used for conditional GET
+type feedCache struct {
+	ETag         string
+	LastModified string
+}
+
+func fetchFeed(ctx context.Context, client *httpClient, feedURL string, cache feedCache) (*RSS, feedCache, error) {
+	req, err := client.newRequest(ctx, feedURL)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
+	}
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
 	}
-	req.Header.Set("User-Agent", "Gator")
-	res, err := http.DefaultClient.Do(req)
+	res, movedTo, err := client.doTrackingRedirects(req)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		// 304 may repeat validators, if not keep the old ones
+		if etag := res.Header.Get("ETag"); etag != "" {
+			cache.ETag = etag
+		}
+		if lastModified := res.Header.Get("Last-Modified"); lastModified != "" {
+			cache.LastModified = lastModified
+		}
+		return nil, cache, errFeedNotModified
+	}
+	if err := checkFeedStatus(res, feedURL); err != nil {
+		return nil, cache, err
+	}
+	newCache := feedCache{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
-	if err = xml.Unmarshal(data, &rss); err != nil {
-		return nil, err
+	contentType := res.Header.Get("Content-Type")
+	if err := checkFeedContent(contentType, feedURL); err != nil {
+		return nil, cache, err
+	}
+	rss, err := parseFeed(data, contentType)
+	if err != nil {
+		if isWebPage(data) {
+			err = errors.New("got a web page instead of a feed")
+		}
+		return nil, cache, &notAFeedError{url: feedURL, contentType: contentType, err: err}
 	}
 	rss.Channel.Title = html.UnescapeString(rss.Channel.Title)
 	rss.Channel.Description = html.UnescapeString(rss.Channel.Description)
-	return &rss, nil
+	rss.MaxAge = parseMaxAge(res.Header.Get("Cache-Control"))
+	rss.MovedTo = movedTo
+	return rss, newCache, nil
+}
+
+// parseFeed recognizes JSON Feed, otherwise checks root element of the document and decodes it as RSS, Atom or RDF
+func parseFeed(data []byte, contentType string) (*RSS, error) {
+	data = toUTF8(data, contentType)
+	if isJSONFeed(data, contentType) {
+		var jsonFeed JSONFeed
+		if err := json.Unmarshal(data, &jsonFeed); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(jsonFeed.Version, jsonFeedVersionPrefix) {
+			return nil, fmt.Errorf("JSON document is not a JSON Feed (version: %q)", jsonFeed.Version)
+		}
+		return jsonFeed.toRSS(), nil
+	}
+	root, err := feedRootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case root.Local == "feed" && root.Space == "http://www.w3.org/2005/Atom":
+		var atom Atom
+		if err := newXMLDecoder(data).Decode(&atom); err != nil {
+			return nil, err
+		}
+		return atom.toRSS(), nil
+	case root.Local == "RDF" && root.Space == "http://www.w3.org/1999/02/22-rdf-syntax-ns#":
+		var rdf RDF
+		if err := newXMLDecoder(data).Decode(&rdf); err != nil {
+			return nil, err
+		}
+		return rdf.toRSS(), nil
+	case root.Local == "rss":
+		var rss RSS
+		if err := newXMLDecoder(data).Decode(&rss); err != nil {
+			return nil, err
+		}
+		rss.Channel.Link = rssLink(rss.Channel.Links)
+		return &rss, nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Local)
+	}
 }
 
-func scrapeFeeds(s *state) error {
-	feeds, err := s.db.GetNextFeedToFetch(context.Background())
+// feedRootElement returns name of the first XML element in the document
+func feedRootElement(data []byte) (xml.Name, error) {
+	decoder := newXMLDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// aggOptions are settings of agg command used while scraping feeds
+type aggOptions struct {
+	interval    time.Duration // time between requests, also the shortest interval of a feed
+	concurrency int
+	feeds       []string     // names or urls of feeds fetched regardless of their schedule
+	limiter     *hostLimiter // spaces requests to hosts, nil means no limits
+}
+
+// aggOnce runs one cycle of scrapeFeeds for cron jobs and tests, error is returned if any feed failed
+func aggOnce(ctx context.Context, s *state, opts aggOptions) error {
+	results, err := scrapeFeeds(ctx, s, opts)
 	if err != nil {
 		return err
 	}
+	fmt.Println()
+	printFeedReport(results)
+	printScrapeSummary(results)
+	failed := 0
+	for _, result := range results {
+		if result.err != nil || result.postErrors > 0 || result.cancelled {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d feeds failed", failed, len(results))
+	}
+	return nil
+}
+
+// feedsToScrape returns feeds given in opts.feeds, or all feeds which are due before the next tick
+func feedsToScrape(ctx context.Context, s *state, opts aggOptions) ([]database.Feed, error) {
+	if len(opts.feeds) == 0 {
+		// feeds due before the next tick are fetched now, otherwise they would wait a whole tick
+		return s.db.GetNextFeedToFetch(ctx, int32(opts.interval/2/time.Second))
+	}
+	feeds, err := s.db.GetFeedsByNameOrUrl(ctx, opts.feeds)
+	if err != nil {
+		return nil, err
+	}
+	for _, wanted := range opts.feeds {
+		found := false
+		for _, feed := range feeds {
+			if feed.Name == wanted || feed.Url.String == wanted {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no feed with name or url: %s", wanted)
+		}
+	}
+	return feeds, nil
+}
+
+// feedResult describes what happened with one feed during scrapeFeeds
+type feedResult struct {
+	url          string
+	newPosts     int
+	updatedPosts int
+	duplicates   int
+	postErrors   int
+	notModified  bool
+	postponed    bool // host asked to wait (429, 503 with Retry-After), feed was not fetched
+	cancelled    bool
+	err          error
+	duration     time.Duration
+}
+
+// scrapeFeeds fetches feeds which are due, in parallel, using pool of "concurrency" workers.
+// When ctx is cancelled no new feeds are started and results of feeds handled so far are returned.
+func scrapeFeeds(ctx context.Context, s *state, opts aggOptions) ([]feedResult, error) {
+	feeds, err := feedsToScrape(ctx, s, opts)
+	if err != nil {
+		return nil, err
+	}
+	feeds = interleaveByHost(feeds)
+	concurrency := max(opts.concurrency, 1)
+	jobs := make(chan database.Feed)
+	var results []feedResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				// every feed writes into own buffer, so output of parallel feeds is not mixed
+				var out bytes.Buffer
+				result := scrapeFeed(ctx, s, feed, opts, &out)
+				mu.Lock()
+				results = append(results, result)
+				fmt.Print(out.String())
+				mu.Unlock()
+			}
+		}()
+	}
+sending:
 	for _, feed := range feeds {
-		url := feed.Url.String
-		rss, err := fetchFeed(context.Background(), url) //w tamtej funkcji dodac url albo jakos przekazac...
+		select {
+		case jobs <- feed:
+		case <-ctx.Done():
+			break sending
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// scrapeFeed fetches one feed and saves its new posts, messages are written to out.
+// Only fetching is interrupted by cancelled ctx, once the feed is downloaded its posts are saved to the end.
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed, opts aggOptions, out io.Writer) feedResult {
+	url := feed.Url.String
+	result := feedResult{url: url}
+	if until, blocked := opts.limiter.blockedUntil(url); blocked {
+		// host answered with 429 or 503 to another feed, it is not asked again before Retry-After
+		dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+		defer cancel()
+		postponeFeed(dbCtx, s, feed, time.Until(until).Round(time.Second), out)
+		result.postponed = true
+		return result
+	}
+	if err := opts.limiter.wait(ctx, url); err != nil {
+		fmt.Fprintln(out, "fetch cancelled:", url)
+		result.cancelled = true
+		return result
+	}
+	// time limit of the feed starts after waiting for its host
+	ctx, cancelFeed := context.WithTimeout(ctx, s.client.fetchTimeout())
+	defer cancelFeed()
+	start := time.Now()
+	cache := feedCache{ETag: feed.Etag.String, LastModified: feed.LastModified.String}
+	rss, newCache, err := fetchFeed(ctx, s.client, url, cache)
+	if err != nil && !errors.Is(err, errFeedNotModified) && errors.Is(ctx.Err(), context.Canceled) {
+		// agg is shutting down, it is not a fault of the feed
+		fmt.Fprintln(out, "fetch cancelled:", url)
+		result.cancelled = true
+		result.duration = time.Since(start)
+		return result
+	}
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveTimeout)
+	defer cancel()
+	if errors.Is(err, errFeedNotModified) {
+		// nothing new on the server, only remember that feed was checked
+		err = s.db.MarkFeedFetched(dbCtx, feed.ID)
 		if err != nil {
-			fmt.Println("error: could not fetch feed:", url)
-			continue
-			//return err
+			fmt.Fprintln(out, "error: could not mark as fetched:", url)
+		}
+		if newCache != cache {
+			saveFeedCache(dbCtx, s, feed.ID, newCache, out)
 		}
-		err = s.db.MarkFeedFetched(context.Background(), feed.ID)
+		scheduleFeed(dbCtx, s, feed, nextFetchInterval(feedInterval(feed), opts.interval, 0, 0), out)
+		fmt.Fprintln(out, "feed not modified:", url)
+		result.notModified = true
+		result.duration = time.Since(start)
+		return result
+	}
+	if err != nil {
+		var rateLimited *rateLimitedError
+		var notFound *notFoundError
+		var notAFeed *notAFeedError
+		switch {
+		case errors.As(err, &rateLimited):
+			// server asks to come back later, it is not counted as failure of the feed.
+			// Other feeds of the host are not fetched until then either.
+			fmt.Fprintln(out, "error:", err)
+			retryAfter := rateLimited.retryAfter
+			if retryAfter == 0 {
+				retryAfter = opts.interval
+			}
+			opts.limiter.block(url, time.Now().Add(min(retryAfter, maxFeedInterval)))
+			postponeFeed(dbCtx, s, feed, max(retryAfter, feedInterval(feed), opts.interval), out)
+			result.postponed = true
+			result.duration = time.Since(start)
+			return result
+		case errors.Is(err, errFeedGone):
+			recordFeedFailure(dbCtx, s, feed, opts, err, out)
+			deactivateFeed(dbCtx, s, feed, err.Error(), out)
+		case errors.As(err, &notFound), errors.As(err, &notAFeed):
+			// url does not give a feed, if it does not come back the feed is deactivated when it is dead
+			recordFeedFailure(dbCtx, s, feed, opts, err, out)
+			if feedHealth(feed.ConsecutiveFailures+1) == feedDead {
+				deactivateFeed(dbCtx, s, feed, err.Error(), out)
+			}
+		default:
+			// server errors and network problems are temporary, feed is retried with backoff
+			recordFeedFailure(dbCtx, s, feed, opts, err, out)
+		}
+		result.err = err
+		result.duration = time.Since(start)
+		return result
+	}
+	if rss.MovedTo != "" && rss.MovedTo != url {
+		moved, err := moveFeed(dbCtx, s, feed, rss.MovedTo)
 		if err != nil {
-			fmt.Println("error: could not mark as fetched:", url)
+			fmt.Fprintln(out, "error: could not move feed", url, "to", rss.MovedTo, ":", err)
+		} else {
+			fmt.Fprintln(out, "feed moved permanently:", url, "->", rss.MovedTo)
+			feed = moved
+		}
+	}
+	err = s.db.MarkFeedFetched(dbCtx, feed.ID)
+	if err != nil {
+		fmt.Fprintln(out, "error: could not mark as fetched:", url)
+		result.err = err
+		result.duration = time.Since(start)
+		return result
+	}
+	saveFeedMetadata(dbCtx, s, feed.ID, rss, out)
+	fetchedAt := time.Now().UTC()
+	var newPosts []newPost
+	for _, item := range rss.Channel.Items {
+		guid := postGUID(item)
+		if guid == "" {
+			// such items would overwrite each other in one row on every fetch
+			fmt.Fprintln(out, "skipping item without guid, link, title and description in feed:", url)
 			continue
-			//return err
 		}
-		var czas_Valid bool
-		for _, item := range rss.Channel.Items {
-			DataStr := item.PubDate
-			czas, err := parseRSSTime(DataStr)
-			if err != nil {
-				czas_Valid = false
-				fmt.Println("error: could not parse string into date:", DataStr)
-			} else {
-				czas_Valid = true
+		DataStr := item.PubDate
+		czas, err := dateparse.Parse(DataStr)
+		if err != nil {
+			// post without readable date is treated as published when it was fetched
+			czas = fetchedAt
+			if strings.TrimSpace(DataStr) != "" {
+				fmt.Fprintln(out, "error: could not parse string into date:", DataStr)
 			}
+		}
 
-			PostParams := database.CreatePostParams{
-				Title:       html.UnescapeString(item.Title),
-				Url:         item.Link,
-				Content:     html.UnescapeString(item.Description), // tu dodac sciaganie artykulu
-				Description: html.UnescapeString(item.Description),
-				PublishedAt: sql.NullTime{Time: czas, Valid: czas_Valid},
-				FeedID:      feed.ID,
-			}
-			err = s.db.CreatePost(context.Background(), PostParams)
+		title := html.UnescapeString(item.Title)
+		description := html.UnescapeString(item.Description)
+		content := html.UnescapeString(postContent(item))
+		PostParams := database.CreatePostParams{
+			Title:       title,
+			Url:         item.Link,
+			Content:     content, // for feeds with extract_content replaced by extractArticles
+			Description: description,
+			PublishedAt: sql.NullTime{Time: czas, Valid: true},
+			FeedID:      feed.ID,
+			Guid:        guid,
+			Author:      sql.NullString{String: postAuthor(item), Valid: postAuthor(item) != ""},
+			Categories:  postCategories(item),
+			ContentHash: postContentHash(title, description, content),
+		}
+		// posts saved before guids were used (migration 011) have their raw link as guid, they get the
+		// real guid here, so the upsert finds them instead of inserting the post again
+		err = s.db.AdoptLegacyPost(dbCtx, database.AdoptLegacyPostParams{
+			Guid:   PostParams.Guid,
+			FeedID: feed.ID,
+			Url:    item.Link,
+		})
+		if err != nil {
+			fmt.Fprintln(out, "error: could not update guid of saved post:", err)
+		}
+		// posts saved before hashes were used have empty hash: the current one is taken without a revision,
+		// stored content can not be compared because it may be the extracted article
+		err = s.db.SetLegacyPostHash(dbCtx, database.SetLegacyPostHashParams{
+			FeedID:      feed.ID,
+			Guid:        PostParams.Guid,
+			ContentHash: PostParams.ContentHash,
+		})
+		if err != nil {
+			fmt.Fprintln(out, "error: could not save hash of saved post:", err)
+		}
+		post, err := s.db.CreatePost(dbCtx, PostParams)
+		if errors.Is(err, sql.ErrNoRows) {
+			// post with the same guid and content is already saved for this feed
+			result.duplicates++
+		} else if err != nil {
+			fmt.Fprintln(out, "error: could not create new post:", err)
+			result.postErrors++
+		} else if post.Inserted {
+			result.newPosts++
+			newPosts = append(newPosts, newPost{id: post.ID, url: item.Link})
+		} else {
+			// publisher changed the post, previous version went to post_revisions
+			result.updatedPosts++
+			newPosts = append(newPosts, newPost{id: post.ID, url: item.Link})
+			err = s.db.PrunePostRevisions(dbCtx, database.PrunePostRevisionsParams{
+				PostID: post.ID,
+				Limit:  maxPostRevisions,
+			})
 			if err != nil {
-				var pqErr *pq.Error
-				if errors.As(err, &pqErr) && pqErr.Code != "23505" { // 23505 = unique_violation
-					fmt.Println("error: could not create new post:", err)
-				}
+				fmt.Fprintln(out, "error: could not remove old revisions of post:", err)
 			}
 		}
+	}
+	// validators are saved only when all posts are stored, otherwise 304 would hide not saved posts until the feed changes
+	if newCache != cache && result.postErrors == 0 {
+		saveFeedCache(dbCtx, s, feed.ID, newCache, out)
+	}
+	if feed.ExtractContent {
+		extractArticles(ctx, s, newPosts, opts.limiter, out)
+	}
+	interval := nextFetchInterval(feedInterval(feed), opts.interval, result.newPosts, feedHint(rss))
+	scheduleFeed(dbCtx, s, feed, interval, out)
+	fmt.Fprintln(out, "feed fetched:", url, "items:", len(rss.Channel.Items), "new posts:", result.newPosts, "updated posts:", result.updatedPosts, "next fetch in:", interval)
+	result.duration = time.Since(start)
+	return result
+}
 
-		//err = feedBasicPrint(*rss)
-		//if err != nil {
-		//	fmt.Println("error: could not print feed:", url)
-		//	continue
+// printFeedReport shows result of every feed from one cycle of scrapeFeeds
+func printFeedReport(results []feedResult) {
+	for _, result := range results {
+		status := "ok"
+		switch {
+		case result.cancelled:
+			status = "cancelled"
+		case result.postponed:
+			status = "postponed, host asked to wait"
+		case result.err != nil:
+			status = "error: " + result.err.Error()
+		case result.notModified:
+			status = "not modified"
+		case result.postErrors > 0:
+			status = fmt.Sprintf("%d posts not saved", result.postErrors)
+		}
+		fmt.Println("Feed:", result.url)
+		fmt.Println("  New posts:", result.newPosts, " | ", "Updated posts:", result.updatedPosts, " | ", "Duplicates:", result.duplicates, " | ", "Duration:", result.duration.Round(time.Millisecond))
+		fmt.Println("  Status:", status)
 	}
-	return nil
 }
 
-func parseRSSTime(dateStr string) (time.Time, error) {
-	var parsedTime time.Time
-	var err error
-	// Try each format until one works
-	for _, format := range rssDateFormats {
-		parsedTime, err = time.Parse(format, dateStr)
-		if err == nil {
-			return parsedTime, nil // Success!
+// printScrapeSummary shows totals of one cycle of scrapeFeeds
+func printScrapeSummary(results []feedResult) {
+	var fetched, notModified, postponed, failed, cancelled, newPosts, updatedPosts int
+	for _, result := range results {
+		switch {
+		case result.cancelled:
+			cancelled++
+		case result.postponed:
+			postponed++
+		case result.err != nil:
+			failed++
+		case result.notModified:
+			notModified++
+		default:
+			fetched++
 		}
+		newPosts += result.newPosts
+		updatedPosts += result.updatedPosts
+	}
+	fmt.Println("Last cycle:", len(results), "feeds |", fetched, "fetched |", notModified, "not modified |",
+		postponed, "postponed |", failed, "failed |", cancelled, "cancelled |", newPosts, "new posts |", updatedPosts, "updated posts")
+}
+
+// recordFeedFailure saves error of the feed and postpones its next fetch with exponential backoff
+func recordFeedFailure(ctx context.Context, s *state, feed database.Feed, opts aggOptions, fetchErr error, out io.Writer) {
+	failures := feed.ConsecutiveFailures + 1
+	backoff := failureBackoff(feedInterval(feed), opts.interval, failures)
+	fmt.Fprintln(out, "error: could not fetch feed:", feed.Url.String)
+	fmt.Fprintf(out, "  %v (failures in a row: %d, next try in: %s)\n", fetchErr, failures, backoff)
+	err := s.db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+		LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+		Backoff:   int32(backoff / time.Second),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not save failure of feed:", err)
 	}
-	return time.Time{}, fmt.Errorf("could not parse date: %s", dateStr)
+}
+
+// postponeFeed moves the next fetch of the feed without changing its interval and failure count
+func postponeFeed(ctx context.Context, s *state, feed database.Feed, delay time.Duration, out io.Writer) {
+	delay = min(delay, maxFeedInterval)
+	err := s.db.PostponeFeedFetch(ctx, database.PostponeFeedFetchParams{
+		Delay: int32(delay / time.Second),
+		ID:    feed.ID,
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not postpone fetch of feed:", err)
+		return
+	}
+	fmt.Fprintln(out, "feed postponed:", feed.Url.String, "next try in:", delay)
+}
+
+// deactivateFeed stops fetching of the feed, it is fetched again only when asked for by agg --once --feed
+func deactivateFeed(ctx context.Context, s *state, feed database.Feed, reason string, out io.Writer) {
+	err := s.db.DeactivateFeed(ctx, database.DeactivateFeedParams{
+		ID:                feed.ID,
+		DeactivatedReason: sql.NullString{String: reason, Valid: true},
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not deactivate feed:", err)
+		return
+	}
+	fmt.Fprintln(out, "feed deactivated:", feed.Url.String, "reason:", reason)
+}
+
+// moveFeed changes url of the feed after permanent redirect. If the new url is already saved as another feed,
+// followers and posts are moved to that feed and the old one is deleted. Returns the feed which has the new url.
+func moveFeed(ctx context.Context, s *state, feed database.Feed, newURL string) (database.Feed, error) {
+	url := sql.NullString{String: newURL, Valid: true}
+	existing, err := s.db.GetFeedByUrl(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = s.db.UpdateFeedUrl(ctx, database.UpdateFeedUrlParams{ID: feed.ID, Url: url})
+		if err != nil {
+			return feed, err
+		}
+		feed.Url = url
+		return feed, nil
+	}
+	if err != nil {
+		return feed, err
+	}
+	// merge is done in one transaction, so a failed step does not leave follows or posts split between feeds
+	tx, err := s.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return feed, err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+	err = qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{ToFeedID: existing.ID, FromFeedID: feed.ID})
+	if err != nil {
+		return feed, err
+	}
+	err = qtx.MoveFeedPosts(ctx, database.MoveFeedPostsParams{ToFeedID: existing.ID, FromFeedID: feed.ID})
+	if err != nil {
+		return feed, err
+	}
+	// posts which the other feed already has are deleted with the old feed
+	err = qtx.DeleteFeed(ctx, feed.ID)
+	if err != nil {
+		return feed, err
+	}
+	if err := tx.Commit(); err != nil {
+		return feed, err
+	}
+	return existing, nil
+}
+
+// feedInterval returns current fetch interval of the feed, 0 if it was not scheduled yet
+func feedInterval(feed database.Feed) time.Duration {
+	return time.Duration(feed.FetchInterval) * time.Second
+}
+
+// scheduleFeed saves new fetch interval of the feed and sets time of its next fetch
+func scheduleFeed(ctx context.Context, s *state, feed database.Feed, interval time.Duration, out io.Writer) {
+	err := s.db.ScheduleFeedFetch(ctx, database.ScheduleFeedFetchParams{
+		ID:            feed.ID,
+		FetchInterval: int32(interval / time.Second),
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not schedule next fetch of feed:", err)
+	}
+}
+
+// saveFeedCache stores ETag and Last-Modified of the feed for the next conditional GET
+func saveFeedCache(ctx context.Context, s *state, feedID uuid.UUID, cache feedCache, out io.Writer) {
+	err := s.db.SetFeedCacheHeaders(ctx, database.SetFeedCacheHeadersParams{
+		ID:           feedID,
+		Etag:         sql.NullString{String: cache.ETag, Valid: cache.ETag != ""},
+		LastModified: sql.NullString{String: cache.LastModified, Valid: cache.LastModified != ""},
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not save cache headers of feed:", err)
+	}
+}
+
+// saveFeedMetadata stores information about the channel given by the feed itself
+func saveFeedMetadata(ctx context.Context, s *state, feedID uuid.UUID, rss *RSS, out io.Writer) {
+	channel := rss.Channel
+	err := s.db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		ID:          feedID,
+		Title:       nullString(channel.Title),
+		Description: nullString(channel.Description),
+		SiteLink:    nullString(channel.Link),
+		ImageUrl:    nullString(channelImage(channel)),
+		Language:    nullString(channel.Language),
+		Generator:   nullString(channel.Generator),
+	})
+	if err != nil {
+		fmt.Fprintln(out, "error: could not save metadata of feed:", err)
+	}
+}
+
+// channelImage returns url of the first image of the channel
+func channelImage(channel Channel) string {
+	for _, image := range channel.Images {
+		if url := strings.TrimSpace(image.URL); url != "" {
+			return url
+		}
+		if url := strings.TrimSpace(image.Href); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// nullString trims value and turns empty string into NULL
+func nullString(value string) sql.NullString {
+	value = strings.TrimSpace(value)
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// postContent prefers full content of the item, if feed gives one
+func postContent(item Item) string {
+	if item.Content != "" {
+		return item.Content
+	}
+	return item.Description
+}
+
+// postGUID identifies item within its feed: guid given by the feed or, if there is none, normalized link.
+// Items without both are identified by hash of title and description, empty result means item can not be identified.
+func postGUID(item Item) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := normalizeURL(item.Link); link != "" {
+		return link
+	}
+	title := strings.TrimSpace(item.Title)
+	description := strings.TrimSpace(item.Description)
+	if title == "" && description == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(title + "\x1f" + description))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// normalizeURL removes parts of the link which do not change the page: utm_* tracking parameters,
+// fragment and trailing slash. Scheme and host are lowercased and query parameters sorted.
+func normalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	// trailing slash is removed from the escaped path, so escapes like %2F keep their meaning
+	escapedPath := strings.TrimRight(u.EscapedPath(), "/")
+	if path, err := neturl.PathUnescape(escapedPath); err == nil {
+		u.Path = path
+		u.RawPath = escapedPath
+	}
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// postContentHash is used to find out if publisher changed title, description or content of the post.
+// Posts saved before migration 012 have empty hash, it is set by SetLegacyPostHash on their next fetch.
+func postContentHash(title, description, content string) string {
+	sum := sha256.Sum256([]byte(title + "\x1f" + description + "\x1f" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// postAuthor returns <author> of the item or dc:creator, if there is no author
+func postAuthor(item Item) string {
+	author := strings.TrimSpace(item.Author)
+	if author == "" {
+		author = strings.TrimSpace(item.Creator)
+	}
+	return html.UnescapeString(author)
+}
+
+// postCategories returns categories of the item without empty ones and repeats
+func postCategories(item Item) []string {
+	categories := []string{}
+	seen := map[string]bool{}
+	for _, category := range item.Categories {
+		category = html.UnescapeString(strings.TrimSpace(category))
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	return categories
 }
 
 func feedDetailRSSPrint(rss RSS) error {
@@ -467,9 +1496,22 @@ func feedBasicPrint(rss RSS) error {
 func feedDetailPostsPrint(posts []database.GetPostsForUserRow) error {
 	// Drukuj poszczegolne elementy feedu
 	for _, item := range posts {
-		fmt.Printf("Title: %s\n", item.Title)
+		if item.Revisions > 0 {
+			fmt.Printf("Title: %s (updated)\n", item.Title)
+		} else {
+			fmt.Printf("Title: %s\n", item.Title)
+		}
 		fmt.Printf("Url: %s\n", item.Url)
 		fmt.Printf("Published: %s\n", item.PublishedAt.Time)
+		if item.Revisions > 0 {
+			fmt.Printf("Updated: %s (%d times)\n", item.UpdatedAt, item.Revisions)
+		}
+		if item.Author.Valid {
+			fmt.Printf("Author: %s\n", item.Author.String)
+		}
+		if len(item.Categories) > 0 {
+			fmt.Printf("Categories: %s\n", strings.Join(item.Categories, ", "))
+		}
 		fmt.Printf("Description: %s\n\n", item.Description)
 	}
 	return nil
@@ -487,8 +1529,14 @@ func main() {
 	//cfg, _ = config.Read()
 	//fmt.Println(cfg)
 
+	client, err := newHTTPClient(&cfg)
+	if err != nil {
+		fmt.Println("error: wrong http settings in config:", err)
+		os.Exit(1)
+	}
+
 	//zainicjuj zmienna ktora jest powazana z cfg odczytana z dysku
-	s := &state{config: &cfg}
+	s := &state{config: &cfg, client: client}
 	var c_commands = commands{komendy: make(map[string]func(*state, command) error)}
 
 	// zarejestruj polecenia:
@@ -498,7 +1546,12 @@ func main() {
 	c_commands.register("users", handlerUsers)
 	c_commands.register("agg", middlewareAgg(handlerAgg))
 	c_commands.register("addfeed", middlewareLoggedIn(handlerAddFeed))
+	c_commands.register("import", middlewareLoggedIn(handlerImport))
+	c_commands.register("export", middlewareLoggedIn(handlerExport))
 	c_commands.register("feeds", handlerFeeds)
+	c_commands.register("feed", handlerFeed)
+	c_commands.register("feedstatus", handlerFeedStatus)
+	c_commands.register("fulltext", handlerFullText)
 	c_commands.register("follow", middlewareLoggedIn(handlerFollow))
 	c_commands.register("following", middlewareLoggedIn(handlerFollowing))
 	c_commands.register("unfollow", middlewareLoggedIn(handlerUnfollow))
@@ -519,6 +1572,7 @@ func main() {
 	}
 	dbQueries := database.New(db)
 	s.db = dbQueries
+	s.sqlDB = db
 
 	// Uruchom polecenie
 	if err := c_commands.run(s, c_command); err != nil {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	neturl "net/url"
+	"strings"
+)
+
+// OPML is the document format used by feed readers to import and export subscriptions
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// OPMLOutline is a feed when it has xmlUrl, otherwise a folder holding other outlines
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// opmlFeed is a feed found in OPML file, folder of nested folders is written as "Parent/Child"
+type opmlFeed struct {
+	name     string
+	url      string
+	folder   string
+	siteLink string
+	err      error // why the entry can not be imported
+}
+
+// opmlFeeds lists feeds of the outlines and of all folders inside them
+func opmlFeeds(outlines []OPMLOutline, folder string) []opmlFeed {
+	var feeds []opmlFeed
+	for _, outline := range outlines {
+		name := strings.TrimSpace(outline.Title)
+		if name == "" {
+			name = strings.TrimSpace(outline.Text)
+		}
+		feedURL := strings.TrimSpace(outline.XMLURL)
+		if feedURL == "" {
+			if len(outline.Outlines) == 0 {
+				feeds = append(feeds, opmlFeed{name: name, folder: folder, err: fmt.Errorf("outline has no xmlUrl")})
+				continue
+			}
+			subfolder := name
+			if folder != "" {
+				subfolder = folder + "/" + name
+			}
+			feeds = append(feeds, opmlFeeds(outline.Outlines, subfolder)...)
+			continue
+		}
+		feed := opmlFeed{name: name, url: feedURL, folder: folder}
+		if parsed, err := neturl.Parse(feedURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			feed.err = fmt.Errorf("invalid xmlUrl: %s", feedURL)
+		}
+		if feed.name == "" {
+			feed.name = feedURL
+		}
+		feeds = append(feeds, feed)
+		// feed outline should not have children, if it has they are imported into its folder
+		feeds = append(feeds, opmlFeeds(outline.Outlines, folder)...)
+	}
+	return feeds
+}
+
+// opmlOutlines turns feeds into outlines, folders "Parent/Child" become nested outlines
+func opmlOutlines(feeds []opmlFeed) []OPMLOutline {
+	var outlines []OPMLOutline
+	folders := map[string]int{}
+	inFolder := map[int][]opmlFeed{}
+	for _, feed := range feeds {
+		if feed.folder == "" {
+			outlines = append(outlines, OPMLOutline{
+				Text:    feed.name,
+				Title:   feed.name,
+				Type:    "rss",
+				XMLURL:  feed.url,
+				HTMLURL: feed.siteLink,
+			})
+			continue
+		}
+		name, rest, _ := strings.Cut(feed.folder, "/")
+		i, ok := folders[name]
+		if !ok {
+			i = len(outlines)
+			folders[name] = i
+			outlines = append(outlines, OPMLOutline{Text: name, Title: name})
+		}
+		feed.folder = rest
+		inFolder[i] = append(inFolder[i], feed)
+	}
+	for i, children := range inFolder {
+		outlines[i].Outlines = opmlOutlines(children)
+	}
+	return outlines
+}
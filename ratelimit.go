@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Geralt28/gator/internal/database"
+)
+
+// hostLimiter keeps agg polite: requests to one host are spaced by hostDelay, all requests together
+// are limited to one per globalDelay and hosts which answered with Retry-After are left alone until then.
+// It is shared by all cycles of agg. Nil limiter does not limit anything.
+type hostLimiter struct {
+	hostDelay   time.Duration
+	globalDelay time.Duration
+
+	mu           sync.Mutex
+	nextGlobal   time.Time
+	nextByHost   map[string]time.Time
+	blockedHosts map[string]time.Time
+}
+
+func newHostLimiter(hostDelay time.Duration, requestsPerSecond float64) *hostLimiter {
+	return &hostLimiter{
+		hostDelay:    hostDelay,
+		globalDelay:  time.Duration(float64(time.Second) / requestsPerSecond),
+		nextByHost:   map[string]time.Time{},
+		blockedHosts: map[string]time.Time{},
+	}
+}
+
+// wait blocks until request to host of rawURL is allowed. Slot of the host is reserved first and the global one
+// only when it comes, so requests waiting for a busy host do not use up the budget of the other hosts.
+func (l *hostLimiter) wait(ctx context.Context, rawURL string) error {
+	if l == nil {
+		return nil
+	}
+	host := urlHost(rawURL)
+	l.mu.Lock()
+	hostAt := later(time.Now(), l.nextByHost[host])
+	l.nextByHost[host] = hostAt.Add(l.hostDelay)
+	l.mu.Unlock()
+	if err := sleepUntil(ctx, hostAt); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	globalAt := later(time.Now(), l.nextGlobal)
+	l.nextGlobal = globalAt.Add(l.globalDelay)
+	l.mu.Unlock()
+	return sleepUntil(ctx, globalAt)
+}
+
+// block stops requests to host of rawURL until given time, it is used after 429 and 503 responses
+func (l *hostLimiter) block(rawURL string, until time.Time) {
+	if l == nil {
+		return
+	}
+	host := urlHost(rawURL)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blockedHosts[host] = later(l.blockedHosts[host], until)
+}
+
+// blockedUntil returns time till which host of rawURL should not get requests
+func (l *hostLimiter) blockedUntil(rawURL string) (time.Time, bool) {
+	if l == nil {
+		return time.Time{}, false
+	}
+	host := urlHost(rawURL)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.blockedHosts[host]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !until.After(time.Now()) {
+		delete(l.blockedHosts, host)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// interleaveByHost orders feeds so feeds of one host are not next to each other: one feed of every host,
+// then the second one of every host and so on. Workers do not wait for the same host when others are free.
+func interleaveByHost(feeds []database.Feed) []database.Feed {
+	var hosts []string
+	byHost := map[string][]database.Feed{}
+	for _, feed := range feeds {
+		host := urlHost(feed.Url.String)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], feed)
+	}
+	ordered := make([]database.Feed, 0, len(feeds))
+	for round := 0; len(ordered) < len(feeds); round++ {
+		for _, host := range hosts {
+			if round < len(byHost[host]) {
+				ordered = append(ordered, byHost[host][round])
+			}
+		}
+	}
+	return ordered
+}
+
+// urlHost returns lowercase host of the url without port, whole url if it can not be parsed
+func urlHost(rawURL string) string {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func sleepUntil(ctx context.Context, at time.Time) error {
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// ******** START:  Struct for RSS 1.0 (RDF) feed *********
+
+// In RSS 1.0 items are siblings of the channel, not its children
+type RDF struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+	Channel RDFChannel `xml:"channel"`
+	Image   RDFImage   `xml:"image"`
+	Items   []RDFItem  `xml:"item"`
+}
+
+type RDFChannel struct {
+	Title           string `xml:"title"`
+	Link            string `xml:"link"`
+	Description     string `xml:"description"`
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	Language        string `xml:"http://purl.org/dc/elements/1.1/ language"`
+}
+
+type RDFImage struct {
+	URL string `xml:"url"`
+}
+
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+// ******** END:  Struct for RSS 1.0 (RDF) feed *********
+
+// toRSS moves items and image, which in RDF are siblings of the channel, into the channel.
+// rdf:about of the item is its guid.
+func (r *RDF) toRSS() *RSS {
+	rss := &RSS{
+		Channel: Channel{
+			Title:           r.Channel.Title,
+			Link:            strings.TrimSpace(r.Channel.Link),
+			Description:     r.Channel.Description,
+			UpdatePeriod:    r.Channel.UpdatePeriod,
+			UpdateFrequency: r.Channel.UpdateFrequency,
+			Language:        r.Channel.Language,
+		},
+	}
+	if r.Image.URL != "" {
+		rss.Channel.Images = []RSSImage{{URL: strings.TrimSpace(r.Image.URL)}}
+	}
+	for _, item := range r.Items {
+		rss.Channel.Items = append(rss.Channel.Items, Item{
+			Title:       item.Title,
+			Link:        strings.TrimSpace(item.Link),
+			Description: item.Description,
+			Content:     item.Content,
+			GUID:        item.About,
+			Creator:     item.Creator,
+			Categories:  item.Subjects,
+			PubDate:     strings.TrimSpace(item.Date),
+		})
+	}
+	return rss
+}
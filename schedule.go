@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFeedInterval is the longest time a feed can wait for the next fetch
+const maxFeedInterval = 24 * time.Hour
+
+// syUpdatePeriods maps sy:updatePeriod values into durations
+var syUpdatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// nextFetchInterval adapts how often feed is fetched: feeds with new posts are fetched twice as often,
+// quiet feeds 1.5 times less often. Result is never shorter than the hint given by the publisher
+// and stays between floor (time between requests of agg) and maxFeedInterval.
+func nextFetchInterval(current, floor time.Duration, newPosts int, hint time.Duration) time.Duration {
+	next := current
+	if next <= 0 {
+		next = floor
+	} else if newPosts > 0 {
+		next = next / 2
+	} else {
+		next = next * 3 / 2
+	}
+	if next < hint {
+		next = hint
+	}
+	if next < floor {
+		next = floor
+	}
+	if next > maxFeedInterval {
+		next = maxFeedInterval
+	}
+	return next
+}
+
+// feedHint returns the longest of polling hints given by feed: <ttl>, sy:updatePeriod/sy:updateFrequency
+// and Cache-Control max-age of the response
+func feedHint(rss *RSS) time.Duration {
+	hint := rss.MaxAge
+	if ttl, err := strconv.Atoi(strings.TrimSpace(rss.Channel.TTL)); err == nil && ttl > 0 {
+		hint = max(hint, time.Duration(ttl)*time.Minute)
+	}
+	if period, ok := syUpdatePeriods[strings.ToLower(strings.TrimSpace(rss.Channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(rss.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1 // default value by the syndication module spec
+		}
+		hint = max(hint, period/time.Duration(frequency))
+	}
+	return min(hint, maxFeedInterval)
+}
+
+// parseMaxAge reads max-age directive from Cache-Control header
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+// Feed health, by number of failed fetches in a row
+const (
+	feedHealthy  = "healthy"
+	feedDegraded = "degraded"
+	feedDead     = "dead"
+	// feedInactive is feed deactivated by gator, e.g. after 410 Gone, it does not depend on failures
+	feedInactive = "inactive"
+	// deadFeedFailures is number of failures in a row after which feed is treated as dead
+	deadFeedFailures = 10
+)
+
+// failureBackoff doubles waiting time with every failure in a row, starting from normal interval of the feed
+func failureBackoff(current, floor time.Duration, failures int32) time.Duration {
+	backoff := max(current, floor)
+	for i := int32(0); i < failures && backoff < maxFeedInterval; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxFeedInterval)
+}
+
+// feedHealth classifies feed by number of failures in a row
+func feedHealth(failures int32) string {
+	switch {
+	case failures == 0:
+		return feedHealthy
+	case failures < deadFeedFailures:
+		return feedDegraded
+	default:
+		return feedDead
+	}
+}
+
+// parseRetryAfter reads Retry-After header given as number of seconds or as HTTP date
+func parseRetryAfter(retryAfter string, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return max(date.Sub(now).Round(time.Second), 0)
+	}
+	return 0
+}
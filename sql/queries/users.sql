@@ -20,14 +20,16 @@ SELECT name FROM users;
 
 
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_link)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
@@ -55,10 +57,11 @@ INNER JOIN users ON users.id = inserted_feed_follow.user_id
 INNER JOIN feeds ON feeds.id = inserted_feed_follow.feed_id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feeds.name AS feedName, users.name As userName FROM feed_follows
+SELECT feeds.name AS feedName, users.name As userName, feeds.url, feeds.site_link, feed_follows.folder FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE feed_follows.user_id = $1;
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name;
 
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
@@ -68,17 +71,27 @@ WHERE feed_follows.feed_id = feeds.id AND feed_follows.user_id = $1 AND feeds.ur
 -- name: MarkFeedFetched :exec
 UPDATE feeds
 SET last_fetched_at = CURRENT_TIMESTAMP,
+    consecutive_failures = 0,
+    active = TRUE,
+    deactivated_reason = NULL,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
 -- name: GetNextFeedToFetch :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at DESC NULLS FIRST
+WHERE active AND (next_fetch_at IS NULL OR next_fetch_at <= CURRENT_TIMESTAMP + sqlc.arg(due_within)::integer * INTERVAL '1 second')
+ORDER BY last_fetched_at ASC NULLS FIRST
 --LIMIT 1
 ;
 
--- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id)
+-- name: CreatePost :one
+WITH post_revision AS (
+    INSERT INTO post_revisions (id, created_at, post_id, title, description, content, content_hash)
+    SELECT gen_random_uuid(), CURRENT_TIMESTAMP, posts.id, posts.title, posts.description, posts.content, posts.content_hash
+    FROM posts
+    WHERE posts.feed_id = $6 AND posts.guid = $7 AND posts.content_hash <> $10 AND posts.content_hash <> ''
+)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id, guid, author, categories, content_hash)
 VALUES (
     gen_random_uuid(),
     CURRENT_TIMESTAMP,
@@ -88,8 +101,37 @@ VALUES (
     $3,
     $4,
     $5,
-    $6 
-);
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    published_at = EXCLUDED.published_at,
+    author = EXCLUDED.author,
+    categories = EXCLUDED.categories,
+    content_hash = EXCLUDED.content_hash,
+    revisions = posts.revisions + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, (xmax = 0) AS inserted;
+
+-- name: AdoptLegacyPost :exec
+UPDATE posts
+SET guid = sqlc.arg(guid),
+    updated_at = CURRENT_TIMESTAMP
+WHERE posts.feed_id = sqlc.arg(feed_id) AND posts.url = sqlc.arg(url) AND posts.guid = posts.url AND posts.guid <> sqlc.arg(guid)
+    AND NOT EXISTS (SELECT 1 FROM posts p WHERE p.feed_id = sqlc.arg(feed_id) AND p.guid = sqlc.arg(guid));
+
+-- name: SetLegacyPostHash :exec
+UPDATE posts
+SET content_hash = $3
+WHERE feed_id = $1 AND guid = $2 AND content_hash = '';
 
 -- name: GetPostsForUser :many
 SELECT * FROM posts
@@ -98,3 +140,125 @@ WHERE feed_follows.user_id = $1
 ORDER BY posts.updated_at 
 LIMIT $2;
 
+
+-- name: SetFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2,
+    last_modified = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET fetch_interval = $2,
+    next_fetch_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET last_error = sqlc.arg(last_error),
+    last_error_at = CURRENT_TIMESTAMP,
+    consecutive_failures = consecutive_failures + 1,
+    next_fetch_at = CURRENT_TIMESTAMP + sqlc.arg(backoff)::integer * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: GetFeedsStatus :many
+SELECT name, url, last_fetched_at, next_fetch_at, last_error, last_error_at, consecutive_failures, active, deactivated_reason FROM feeds
+ORDER BY consecutive_failures DESC, name;
+
+-- name: GetFeedsByNameOrUrl :many
+SELECT * FROM feeds
+WHERE name = ANY(sqlc.arg(names)::text[]) OR url = ANY(sqlc.arg(names)::text[])
+ORDER BY name;
+
+-- name: SetFeedExtractContent :execrows
+UPDATE feeds
+SET extract_content = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE url = $1 OR name = $1;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET content = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: PrunePostRevisions :exec
+DELETE FROM post_revisions
+WHERE post_id = $1 AND id NOT IN (
+    SELECT id FROM post_revisions
+    WHERE post_id = $1
+    ORDER BY created_at DESC
+    LIMIT $2
+);
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    description = $3,
+    site_link = $4,
+    image_url = $5,
+    language = $6,
+    generator = $7,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetFeedDetails :one
+SELECT feeds.*, users.name AS user_name,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count
+FROM feeds
+LEFT JOIN users ON feeds.user_id = users.id
+WHERE feeds.name = $1 OR feeds.url = $1
+LIMIT 1;
+
+-- name: GetFeedByUrl :one
+SELECT * FROM feeds
+WHERE url = $1;
+
+-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET url = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+SELECT gen_random_uuid(), created_at, CURRENT_TIMESTAMP, user_id, sqlc.arg(to_feed_id), folder
+FROM feed_follows
+WHERE feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT ON CONSTRAINT user_feed_constr DO NOTHING;
+
+-- name: MoveFeedPosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = sqlc.arg(from_feed_id)
+    AND guid NOT IN (SELECT guid FROM posts WHERE feed_id = sqlc.arg(to_feed_id));
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: DeactivateFeed :exec
+UPDATE feeds
+SET active = FALSE,
+    deactivated_reason = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: PostponeFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = CURRENT_TIMESTAMP + sqlc.arg(delay)::integer * INTERVAL '1 second',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: ImportFeedFollow :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
+VALUES (gen_random_uuid(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3)
+ON CONFLICT ON CONSTRAINT user_feed_constr DO UPDATE
+SET folder = COALESCE(EXCLUDED.folder, feed_follows.folder),
+    updated_at = CURRENT_TIMESTAMP
+RETURNING (xmax = 0) AS inserted;
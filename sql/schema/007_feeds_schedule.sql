@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN next_fetch_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN fetch_interval,
+DROP COLUMN next_fetch_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN last_error TEXT,
+ADD COLUMN last_error_at TIMESTAMP,
+ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN last_error,
+DROP COLUMN last_error_at,
+DROP COLUMN consecutive_failures;
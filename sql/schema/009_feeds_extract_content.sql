@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN extract_content BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN extract_content;
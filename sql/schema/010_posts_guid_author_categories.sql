@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE posts 
+ADD COLUMN guid TEXT,
+ADD COLUMN author TEXT,
+ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE posts 
+DROP COLUMN guid,
+DROP COLUMN author,
+DROP COLUMN categories;
@@ -0,0 +1,17 @@
+-- +goose Up
+UPDATE posts SET guid = url WHERE guid IS NULL OR guid = '';
+
+DELETE FROM posts a
+USING posts b
+WHERE a.feed_id = b.feed_id AND a.guid = b.guid AND a.ctid > b.ctid;
+
+ALTER TABLE posts 
+ALTER COLUMN guid SET NOT NULL,
+DROP CONSTRAINT posts_url_key,
+ADD CONSTRAINT feed_guid_constr UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts 
+DROP CONSTRAINT feed_guid_constr,
+ADD CONSTRAINT posts_url_key UNIQUE (url),
+ALTER COLUMN guid DROP NOT NULL;
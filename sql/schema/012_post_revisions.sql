@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE posts 
+ADD COLUMN content_hash TEXT NOT NULL DEFAULT '',
+ADD COLUMN revisions INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE post_revisions(
+id UUID PRIMARY KEY,
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+title TEXT NOT NULL,
+description TEXT NOT NULL,
+content TEXT NOT NULL,
+content_hash TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE post_revisions;
+
+ALTER TABLE posts 
+DROP COLUMN content_hash,
+DROP COLUMN revisions;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN description TEXT,
+ADD COLUMN site_link TEXT;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN description,
+DROP COLUMN site_link;
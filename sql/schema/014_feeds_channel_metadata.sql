@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN title TEXT,
+ADD COLUMN image_url TEXT,
+ADD COLUMN language TEXT,
+ADD COLUMN generator TEXT;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN title,
+DROP COLUMN image_url,
+DROP COLUMN language,
+DROP COLUMN generator;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds 
+ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN deactivated_reason TEXT;
+
+-- +goose Down
+ALTER TABLE feeds 
+DROP COLUMN active,
+DROP COLUMN deactivated_reason;
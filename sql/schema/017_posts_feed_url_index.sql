@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX posts_feed_url_idx ON posts (feed_id, url);
+
+-- +goose Down
+DROP INDEX posts_feed_url_idx;